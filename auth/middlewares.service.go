@@ -1,10 +1,19 @@
 ﻿package auth
 
 import (
+	"blogpessoal/database"
+	"blogpessoal/model"
+	"context"
 	"encoding/json"
 	"net/http"
+	"time"
 )
 
+
+type chaveContexto string
+
+const chaveUsuarioLogado chaveContexto = "usuarioLogado"
+
 func SetMiddlewareJSON(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -14,12 +23,86 @@ func SetMiddlewareJSON(next http.HandlerFunc) http.HandlerFunc {
 
 func SetMiddlewareAuthentication(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		err := TokenValid(r)
+		claims, err := LerToken(r)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			json.NewEncoder(w).Encode("Usuario não Autenticado!")
 			return
 		}
+
+		var usuario model.Usuario
+		if claims.Usuario != "" {
+			database.Instance.Where("usuario = ?", claims.Usuario).Find(&usuario)
+		}
+		// Tokens emitidos antes da última troca de senha não valem mais
+		if claims.Versao != usuario.VersaoToken {
+			usuario = model.Usuario{}
+		}
+		// Tokens de Sessoes encerradas, ou emitidos antes do controle de Sessoes, não valem mais
+		var sessao model.Sessao
+		if claims.Sessao != "" && usuario.ID != 0 {
+			database.Instance.Where("identificador = ? AND usuario_id = ? AND expira_em > ?", claims.Sessao, usuario.ID, time.Now()).Find(&sessao)
+		}
+		if usuario.ID == 0 || sessao.ID == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode("Usuario não Autenticado!")
+			return
+		}
+		registrarAtividade(&sessao, r)
+
+		// Contas suspensas ou banidas são recusadas mesmo com um token válido
+		if usuario.SituacaoAtual() != model.SituacaoAtivo {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(MensagemSituacao(usuario))
+			return
+		}
+		// A senha antiga não libera a troca: a nova só é definida pelo link enviado por e-mail
+		if usuario.RedefinirSenha {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode("É preciso redefinir a senha pelo link enviado por e-mail antes de continuar!")
+			return
+		}
+
+		contexto := context.WithValue(r.Context(), chaveUsuarioLogado, usuario)
+		next(w, r.WithContext(context.WithValue(contexto, chaveSessaoAtual, sessao)))
+	}
+}
+
+// SetMiddlewareAdmin deve ser usado depois de SetMiddlewareAuthentication
+func SetMiddlewareAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if UsuarioLogado(r).Perfil != model.PerfilAdmin {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode("Acesso Negado!")
+			return
+		}
 		next(w, r)
 	}
 }
+
+// MensagemSituacao explica ao Usuario por que a conta dele está bloqueada
+func MensagemSituacao(usuario model.Usuario) string {
+
+	if usuario.SituacaoAtual() == model.SituacaoPendente {
+		return "Cadastro aguardando a aprovação de um administrador!"
+	}
+
+	mensagem := "Usuario Banido!"
+	if usuario.SituacaoAtual() == model.SituacaoSuspenso {
+		mensagem = "Usuario Suspenso!"
+		if usuario.SuspensoAte != nil {
+			mensagem = "Usuario Suspenso até " + usuario.SuspensoAte.Format("02/01/2006 15:04") + "!"
+		}
+	}
+	if usuario.MotivoSuspensao != "" {
+		mensagem += " Motivo: " + usuario.MotivoSuspensao
+	}
+
+	return mensagem
+}
+
+// UsuarioLogado retorna o Usuario carregado por SetMiddlewareAuthentication
+func UsuarioLogado(r *http.Request) model.Usuario {
+	usuario, _ := r.Context().Value(chaveUsuarioLogado).(model.Usuario)
+	return usuario
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"blogpessoal/database"
+	"blogpessoal/model"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Intervalo mínimo entre duas atualizações da última atividade de uma Sessao,
+// para não gravar no banco a cada requisição
+const intervaloAtividadeSessao = time.Minute
+
+const chaveSessaoAtual chaveContexto = "sessaoAtual"
+
+// CriarSessao registra o login do Usuario no dispositivo da requisição e gera o token da nova Sessao.
+// Sem um nome informado pelo cliente, o dispositivo é descrito a partir do User-Agent.
+func CriarSessao(usuario model.Usuario, dispositivo string, r *http.Request) (string, error) {
+
+	identificador := make([]byte, 32)
+	if _, err := rand.Read(identificador); err != nil {
+		return "", err
+	}
+
+	userAgent := limitarTexto(r.UserAgent(), 255)
+	if dispositivo == "" {
+		dispositivo = descreverDispositivo(userAgent)
+	}
+
+	agora := time.Now()
+	sessao := model.Sessao{
+		Identificador:   hex.EncodeToString(identificador),
+		UsuarioID:       usuario.ID,
+		Dispositivo:     limitarTexto(dispositivo, 100),
+		IP:              IPCliente(r),
+		UserAgent:       userAgent,
+		UltimaAtividade: agora,
+		ExpiraEm:        agora.Add(DuracaoToken),
+	}
+	if err := database.Instance.Omit("Usuario").Create(&sessao).Error; err != nil {
+		return "", err
+	}
+
+	return CreateToken(usuario.Usuario, usuario.VersaoToken, sessao.Identificador)
+}
+
+// SessaoAtual retorna a Sessao do token validado por SetMiddlewareAuthentication
+func SessaoAtual(r *http.Request) model.Sessao {
+	sessao, _ := r.Context().Value(chaveSessaoAtual).(model.Sessao)
+	return sessao
+}
+
+// IPCliente retorna o IP de quem fez a requisição. O servidor só escuta em 127.0.0.1,
+// atrás de um proxy reverso, que acrescenta o IP de quem o acessou ao fim de X-Forwarded-For.
+// Os endereços anteriores vêm do próprio cliente e podem ser forjados, então vale o último.
+func IPCliente(r *http.Request) string {
+
+	if encaminhados := r.Header.Values("X-Forwarded-For"); len(encaminhados) > 0 {
+		enderecos := strings.Split(encaminhados[len(encaminhados)-1], ",")
+		if ip := strings.TrimSpace(enderecos[len(enderecos)-1]); ip != "" {
+			return limitarTexto(ip, 45)
+		}
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return limitarTexto(r.RemoteAddr, 45)
+	}
+	return ip
+}
+
+// registrarAtividade atualiza a última atividade e o IP da Sessao, no máximo uma vez por intervalo
+func registrarAtividade(sessao *model.Sessao, r *http.Request) {
+
+	agora := time.Now()
+	if agora.Sub(sessao.UltimaAtividade) < intervaloAtividadeSessao {
+		return
+	}
+
+	sessao.UltimaAtividade = agora
+	sessao.IP = IPCliente(r)
+	database.Instance.Model(sessao).UpdateColumns(map[string]interface{}{"ultima_atividade": agora, "ip": sessao.IP})
+}
+
+// Navegadores e sistemas reconhecidos no User-Agent, na ordem em que são procurados
+var (
+	navegadores = [][2]string{{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"}, {"Safari/", "Safari"}, {"curl/", "curl"}, {"PostmanRuntime/", "Postman"}}
+	sistemas    = [][2]string{{"Android", "Android"}, {"iPhone", "iPhone"}, {"iPad", "iPad"}, {"Windows", "Windows"}, {"Mac OS X", "macOS"}, {"Linux", "Linux"}}
+)
+
+// descreverDispositivo resume o User-Agent em "navegador no sistema"
+func descreverDispositivo(userAgent string) string {
+
+	navegador, sistema := "", ""
+	for _, candidato := range navegadores {
+		if strings.Contains(userAgent, candidato[0]) {
+			navegador = candidato[1]
+			break
+		}
+	}
+	for _, candidato := range sistemas {
+		if strings.Contains(userAgent, candidato[0]) {
+			sistema = candidato[1]
+			break
+		}
+	}
+
+	switch {
+	case navegador != "" && sistema != "":
+		return navegador + " no " + sistema
+	case navegador != "":
+		return navegador
+	case sistema != "":
+		return sistema
+	}
+	return "Dispositivo desconhecido"
+}
+
+func limitarTexto(texto string, tamanho int) string {
+	if len(texto) > tamanho {
+		texto = texto[:tamanho]
+	}
+	return strings.ToValidUTF8(texto, "")
+}
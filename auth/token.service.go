@@ -2,6 +2,7 @@
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,11 +14,18 @@ import (
 	jwt "github.com/golang-jwt/jwt/v4"
 )
 
-func CreateToken(usuario string) (string, error) {
+// DuracaoToken é a validade dos tokens e das Sessoes criadas no login
+const DuracaoToken = time.Hour
+
+// CreateToken gera o token da Sessao do Usuario com a versão atual dos tokens dele. Os tokens de versões
+// anteriores ou de Sessoes encerradas são recusados por SetMiddlewareAuthentication.
+func CreateToken(usuario string, versao uint, sessao string) (string, error) {
 	claims := jwt.MapClaims{}
 	claims["authorized"] = true
 	claims["usuario"] = usuario
-	claims["exp"] = time.Now().Add(time.Hour * 1).Unix() //Token expires after 1 hour
+	claims["versao"] = versao
+	claims["sessao"] = sessao
+	claims["exp"] = time.Now().Add(DuracaoToken).Unix() //Token expires after 1 hour
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(os.Getenv("secret")))
 
@@ -76,6 +84,48 @@ func ExtractTokenID(r *http.Request) (uint32, error) {
 	return 0, nil
 }
 
+// ClaimsToken são os dados gravados no token por CreateToken
+type ClaimsToken struct {
+	// E-mail do Usuario
+	Usuario string
+	// Versão dos tokens do Usuario; tokens sem versão são da versão zero
+	Versao uint
+	// Identificador da Sessao
+	Sessao string
+}
+
+// LerToken confere a assinatura e a validade do token da requisição e lê todas as claims de uma vez
+func LerToken(r *http.Request) (ClaimsToken, error) {
+
+	var dados ClaimsToken
+	tokenString := ExtractToken(r)
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(os.Getenv("API_SECRET")), nil
+	})
+	if err != nil {
+		return dados, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return dados, errors.New("token inválido")
+	}
+
+	dados.Usuario, _ = claims["usuario"].(string)
+	versao, _ := claims["versao"].(float64)
+	dados.Versao = uint(versao)
+	dados.Sessao, _ = claims["sessao"].(string)
+	return dados, nil
+}
+
+// ExtractTokenUsuario retorna o usuario (e-mail) gravado nas claims do token
+func ExtractTokenUsuario(r *http.Request) (string, error) {
+	claims, err := LerToken(r)
+	return claims.Usuario, err
+}
+
 //Pretty display the claims licely in the terminal
 func Pretty(data interface{}) {
 	b, err := json.MarshalIndent(data, "", " ")
@@ -0,0 +1,230 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Entrada guarda uma resposta já renderizada e as tabelas das quais ela depende
+type Entrada struct {
+	Corpo             []byte
+	Header            http.Header
+	ETag              string
+	UltimaModificacao time.Time
+	tabelas           []string
+}
+
+// Cache de respostas em memória, invalidado por tabela
+type Cache struct {
+	mu          sync.Mutex
+	maxEntradas int
+	entradas    map[string]*Entrada
+	porTabela   map[string]map[string]struct{}
+	geracoes    map[string]uint64
+}
+
+var Instance = New(1000)
+
+func New(maxEntradas int) *Cache {
+	return &Cache{
+		maxEntradas: maxEntradas,
+		entradas:    make(map[string]*Entrada),
+		porTabela:   make(map[string]map[string]struct{}),
+		geracoes:    make(map[string]uint64),
+	}
+}
+
+func (c *Cache) Get(chave string) (*Entrada, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entrada, ok := c.entradas[chave]
+	return entrada, ok
+}
+
+// Geracao retorna um retrato das gerações das tabelas informadas,
+// usado para descartar respostas calculadas durante uma alteração
+func (c *Cache) Geracao(tabelas []string) []uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	geracao := make([]uint64, len(tabelas))
+	for i, tabela := range tabelas {
+		geracao[i] = c.geracoes[tabela]
+	}
+	return geracao
+}
+
+// Set grava a resposta, exceto se alguma das tabelas foi alterada desde a geração informada
+func (c *Cache) Set(chave string, corpo []byte, header http.Header, tabelas []string, geracao []uint64) *Entrada {
+	soma := sha256.Sum256(corpo)
+	entrada := &Entrada{
+		Corpo:             corpo,
+		Header:            header.Clone(),
+		ETag:              `"` + hex.EncodeToString(soma[:16]) + `"`,
+		UltimaModificacao: time.Now().UTC().Truncate(time.Second),
+		tabelas:           tabelas,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, tabela := range tabelas {
+		if c.geracoes[tabela] != geracao[i] {
+			return entrada
+		}
+	}
+
+	if _, ok := c.entradas[chave]; !ok && c.maxEntradas > 0 && len(c.entradas) >= c.maxEntradas {
+		for antiga := range c.entradas {
+			c.remover(antiga)
+			break
+		}
+	}
+
+	c.entradas[chave] = entrada
+	for _, tabela := range tabelas {
+		if c.porTabela[tabela] == nil {
+			c.porTabela[tabela] = make(map[string]struct{})
+		}
+		c.porTabela[tabela][chave] = struct{}{}
+	}
+	return entrada
+}
+
+// Invalidate remove todas as respostas que dependem da tabela
+func (c *Cache) Invalidate(tabela string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.geracoes[tabela]++
+	for chave := range c.porTabela[tabela] {
+		c.remover(chave)
+	}
+}
+
+// InvalidateAll esvazia o cache
+func (c *Cache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for tabela := range c.geracoes {
+		c.geracoes[tabela]++
+	}
+	for tabela := range c.porTabela {
+		c.geracoes[tabela]++
+	}
+	c.entradas = make(map[string]*Entrada)
+	c.porTabela = make(map[string]map[string]struct{})
+}
+
+func (c *Cache) remover(chave string) {
+	entrada, ok := c.entradas[chave]
+	if !ok {
+		return
+	}
+	for _, tabela := range entrada.tabelas {
+		delete(c.porTabela[tabela], chave)
+	}
+	delete(c.entradas, chave)
+}
+
+// tabelasAlteradas acumula as tabelas gravadas dentro de uma Transaction
+type tabelasAlteradas struct {
+	mu      sync.Mutex
+	tabelas map[string]struct{}
+	todas   bool
+}
+
+type chaveTransacao struct{}
+
+func (alteradas *tabelasAlteradas) marcar(tabela string) {
+	alteradas.mu.Lock()
+	defer alteradas.mu.Unlock()
+
+	if tabela == "" {
+		alteradas.todas = true
+		return
+	}
+	alteradas.tabelas[tabela] = struct{}{}
+}
+
+func (alteradas *tabelasAlteradas) invalidar() {
+	alteradas.mu.Lock()
+	defer alteradas.mu.Unlock()
+
+	if alteradas.todas {
+		Instance.InvalidateAll()
+		return
+	}
+	for tabela := range alteradas.tabelas {
+		Instance.Invalidate(tabela)
+	}
+}
+
+// Transaction executa fc em uma transação do GORM e só invalida as tabelas gravadas depois do commit.
+// Invalidar a cada comando deixaria uma requisição iniciada antes do commit gravar no cache,
+// com a geração nova, os dados anteriores à transação.
+func Transaction(db *gorm.DB, fc func(tx *gorm.DB) error) error {
+
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Transações aninhadas usam savepoints e são invalidadas pelo commit da externa
+	if _, ok := ctx.Value(chaveTransacao{}).(*tabelasAlteradas); ok {
+		return db.Transaction(fc)
+	}
+
+	alteradas := &tabelasAlteradas{tabelas: make(map[string]struct{})}
+	err := db.WithContext(context.WithValue(ctx, chaveTransacao{}, alteradas)).Transaction(fc)
+	if err == nil {
+		alteradas.invalidar()
+	}
+	return err
+}
+
+// invalidarTabela invalida a tabela gravada pelo comando ou, dentro de uma Transaction,
+// apenas a anota para ser invalidada depois do commit
+func invalidarTabela(db *gorm.DB, tabela string) {
+	if db.Statement.Context != nil {
+		if alteradas, ok := db.Statement.Context.Value(chaveTransacao{}).(*tabelasAlteradas); ok {
+			alteradas.marcar(tabela)
+			return
+		}
+	}
+
+	if tabela == "" {
+		Instance.InvalidateAll()
+		return
+	}
+	Instance.Invalidate(tabela)
+}
+
+// RegisterCallbacks invalida o cache sempre que o GORM grava em uma tabela.
+// Os callbacks rodam depois do commit da transação implícita de cada comando; as gravações
+// feitas dentro de Transaction são invalidadas quando a transação inteira é confirmada.
+func RegisterCallbacks(db *gorm.DB) {
+	invalidar := func(db *gorm.DB) {
+		if db.Error != nil || db.Statement.RowsAffected == 0 {
+			return
+		}
+		invalidarTabela(db, db.Statement.Table)
+	}
+
+	db.Callback().Create().After("gorm:commit_or_rollback_transaction").Register("cache:invalidar", invalidar)
+	db.Callback().Update().After("gorm:commit_or_rollback_transaction").Register("cache:invalidar", invalidar)
+	db.Callback().Delete().After("gorm:commit_or_rollback_transaction").Register("cache:invalidar", invalidar)
+	db.Callback().Raw().After("gorm:raw").Register("cache:invalidar", func(db *gorm.DB) {
+		if db.Error == nil {
+			invalidarTabela(db, "")
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+
+	"blogpessoal/auth"
+)
+
+// SetMiddlewareCache guarda a resposta do handler por rota e usuario,
+// respondendo às requisições condicionais com 304 Not Modified.
+// As tabelas informadas são as que invalidam a resposta quando alteradas.
+func SetMiddlewareCache(next http.HandlerFunc, tabelas ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		usuario, _ := auth.ExtractTokenUsuario(r)
+		chave := r.Method + " " + r.URL.RequestURI() + "|" + usuario
+
+		if entrada, ok := Instance.Get(chave); ok {
+			responder(w, r, entrada)
+			return
+		}
+
+		geracao := Instance.Geracao(tabelas)
+		gravador := &gravadorResposta{header: http.Header{}, status: http.StatusOK}
+		next(gravador, r)
+
+		if gravador.status != http.StatusOK {
+			copiarHeader(w.Header(), gravador.header)
+			w.WriteHeader(gravador.status)
+			w.Write(gravador.corpo.Bytes())
+			return
+		}
+
+		entrada := Instance.Set(chave, gravador.corpo.Bytes(), gravador.header, tabelas, geracao)
+		responder(w, r, entrada)
+	}
+}
+
+func responder(w http.ResponseWriter, r *http.Request, entrada *Entrada) {
+	copiarHeader(w.Header(), entrada.Header)
+	w.Header().Set("ETag", entrada.ETag)
+	w.Header().Set("Last-Modified", entrada.UltimaModificacao.Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	if naoModificado(r, entrada) {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(entrada.Corpo)
+}
+
+func naoModificado(r *http.Request, entrada *Entrada) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, etag := range strings.Split(ifNoneMatch, ",") {
+			etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+			if etag == "*" || etag == entrada.ETag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince := r.Header.Get("If-Modified-Since"); ifModifiedSince != "" {
+		data, err := time.Parse(http.TimeFormat, ifModifiedSince)
+		return err == nil && !entrada.UltimaModificacao.After(data)
+	}
+
+	return false
+}
+
+func copiarHeader(destino, origem http.Header) {
+	for chave, valores := range origem {
+		destino[chave] = valores
+	}
+}
+
+type gravadorResposta struct {
+	header http.Header
+	status int
+	corpo  bytes.Buffer
+}
+
+func (g *gravadorResposta) Header() http.Header {
+	return g.header
+}
+
+func (g *gravadorResposta) Write(b []byte) (int, error) {
+	return g.corpo.Write(b)
+}
+
+func (g *gravadorResposta) WriteHeader(status int) {
+	g.status = status
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"blogpessoal/database"
+	"blogpessoal/mail"
+	"blogpessoal/services"
+	"blogpessoal/site"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// RunCommand executa o subcomando informado na linha de comando, ex.:
+//
+//	go run . importar-wxr -arquivo wordpress.xml -dry-run
+func RunCommand(args []string) {
+
+	switch args[0] {
+	case "importar-wxr":
+		importarWXR(args[1:])
+	case "exportar-markdown":
+		exportarMarkdown(args[1:])
+	case "importar-markdown":
+		importarMarkdown(args[1:])
+	case "gerar-site":
+		gerarSite(args[1:])
+	case "enviar-resumos":
+		enviarResumos(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Comando desconhecido: %s\n", args[0])
+		fmt.Fprintln(os.Stderr, "Comandos disponíveis: importar-wxr, exportar-markdown, importar-markdown, gerar-site, enviar-resumos")
+		os.Exit(2)
+	}
+}
+
+func importarWXR(args []string) {
+
+	comando := flag.NewFlagSet("importar-wxr", flag.ExitOnError)
+	caminho := comando.String("arquivo", "", "Arquivo WXR exportado do WordPress")
+	dryRun := comando.Bool("dry-run", false, "Simula a importação sem gravar nada")
+	comando.Parse(args)
+
+	if *caminho == "" {
+		comando.Usage()
+		os.Exit(2)
+	}
+
+	arquivo, err := os.Open(*caminho)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer arquivo.Close()
+
+	relatorio, err := services.ImportarWXR(database.Instance, arquivo, *dryRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	imprimirRelatorio(relatorio)
+}
+
+func exportarMarkdown(args []string) {
+
+	comando := flag.NewFlagSet("exportar-markdown", flag.ExitOnError)
+	diretorio := comando.String("dir", "content", "Diretório de destino dos arquivos Markdown")
+	comando.Parse(args)
+
+	total, err := services.ExportarMarkdown(database.Instance, *diretorio)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("%d postagens exportadas para %s", total, *diretorio)
+}
+
+func importarMarkdown(args []string) {
+
+	comando := flag.NewFlagSet("importar-markdown", flag.ExitOnError)
+	diretorio := comando.String("dir", "content", "Diretório com os arquivos Markdown")
+	dryRun := comando.Bool("dry-run", false, "Simula a importação sem gravar nada")
+	comando.Parse(args)
+
+	relatorio, err := services.ImportarMarkdown(database.Instance, *diretorio, *dryRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	imprimirRelatorio(relatorio)
+}
+
+func gerarSite(args []string) {
+
+	comando := flag.NewFlagSet("gerar-site", flag.ExitOnError)
+	saida := comando.String("saida", "public", "Diretório onde o site será gerado")
+	layout := comando.String("layout", "padrao", "Layout embutido usado nas páginas")
+	titulo := comando.String("titulo", "Blog Pessoal", "Título do site")
+	url := comando.String("url", "", "Endereço público do site, usado nos links e feeds")
+	porPagina := comando.Int("por-pagina", 10, "Postagens por página do índice")
+	comando.Parse(args)
+
+	total, err := site.Gerar(database.Instance, site.Opcoes{
+		Saida:     *saida,
+		Layout:    *layout,
+		Titulo:    *titulo,
+		URL:       *url,
+		PorPagina: *porPagina,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("%d arquivos gerados em %s", total, *saida)
+}
+
+func enviarResumos(args []string) {
+
+	comando := flag.NewFlagSet("enviar-resumos", flag.ExitOnError)
+	comando.Parse(args)
+
+	enviados, err := services.EnviarResumos(database.Instance, mail.Instance, opcoesResumo(), time.Now())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("%d resumos enviados", enviados)
+}
+
+func imprimirRelatorio(relatorio interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(relatorio)
+}
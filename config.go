@@ -5,8 +5,36 @@ import (
 	"github.com/spf13/viper"
 )
 type Config struct {
-	Port             string `mapstructure:"port"`
-	ConnectionString string `mapstructure:"connection_string"`
+	Port                string `mapstructure:"port"`
+	ConnectionString    string `mapstructure:"connection_string"`
+	CacheMaxEntradas    int    `mapstructure:"cache_max_entradas"`
+	LixeiraRetencaoDias int    `mapstructure:"lixeira_retencao_dias"`
+	ExclusaoContaDias     int    `mapstructure:"exclusao_conta_dias"`
+	ExclusaoContaPolitica string `mapstructure:"exclusao_conta_politica"`
+	SenhaTamanhoMinimo         int    `mapstructure:"senha_tamanho_minimo"`
+	SenhaExigirMaiuscula       bool   `mapstructure:"senha_exigir_maiuscula"`
+	SenhaExigirMinuscula       bool   `mapstructure:"senha_exigir_minuscula"`
+	SenhaExigirNumero          bool   `mapstructure:"senha_exigir_numero"`
+	SenhaExigirSimbolo         bool   `mapstructure:"senha_exigir_simbolo"`
+	SenhaProibirDadosPessoais  bool   `mapstructure:"senha_proibir_dados_pessoais"`
+	SenhasVazadasArquivo       string `mapstructure:"senhas_vazadas_arquivo"`
+	HashSenhaAlgoritmo         string `mapstructure:"hash_senha_algoritmo"`
+	BcryptCusto                int    `mapstructure:"bcrypt_custo"`
+	Argon2Memoria              uint32 `mapstructure:"argon2_memoria"`
+	Argon2Iteracoes            uint32 `mapstructure:"argon2_iteracoes"`
+	Argon2Paralelismo          uint8  `mapstructure:"argon2_paralelismo"`
+	CadastroModo               string `mapstructure:"cadastro_modo"`
+	RedefinicaoSenhaHoras      int    `mapstructure:"redefinicao_senha_horas"`
+	TituloSite          string `mapstructure:"titulo_site"`
+	URLSite             string `mapstructure:"url_site"`
+	URLAPI              string `mapstructure:"url_api"`
+	Mailer              string `mapstructure:"mailer"`
+	MailerRemetente     string `mapstructure:"mailer_remetente"`
+	MailerDiretorio     string `mapstructure:"mailer_diretorio"`
+	SMTPHost            string `mapstructure:"smtp_host"`
+	SMTPPorta           int    `mapstructure:"smtp_porta"`
+	SMTPUsuario         string `mapstructure:"smtp_usuario"`
+	SMTPSenha           string `mapstructure:"smtp_senha"`
 }
 var AppConfig *Config
 func LoadAppConfig(){
@@ -14,6 +42,26 @@ func LoadAppConfig(){
 	viper.AddConfigPath(".")
 	viper.SetConfigName("config")
 	viper.SetConfigType("json")
+	viper.SetDefault("cache_max_entradas", 1000)
+	viper.SetDefault("lixeira_retencao_dias", 30)
+	viper.SetDefault("exclusao_conta_dias", 15)
+	viper.SetDefault("exclusao_conta_politica", "anonimizar")
+	viper.SetDefault("senha_tamanho_minimo", 8)
+	viper.SetDefault("senha_proibir_dados_pessoais", true)
+	viper.SetDefault("hash_senha_algoritmo", "argon2id")
+	viper.SetDefault("bcrypt_custo", 10)
+	viper.SetDefault("argon2_memoria", 19456)
+	viper.SetDefault("argon2_iteracoes", 2)
+	viper.SetDefault("argon2_paralelismo", 1)
+	viper.SetDefault("cadastro_modo", "aberto")
+	viper.SetDefault("redefinicao_senha_horas", 24)
+	viper.SetDefault("titulo_site", "Blog Pessoal")
+	viper.SetDefault("url_site", "http://localhost:8080")
+	viper.SetDefault("url_api", "http://localhost:8080")
+	viper.SetDefault("mailer", "arquivo")
+	viper.SetDefault("mailer_remetente", "Blog Pessoal <nao-responda@localhost>")
+	viper.SetDefault("mailer_diretorio", "emails")
+	viper.SetDefault("smtp_porta", 587)
 	err := viper.ReadInConfig()
 	if err != nil {
 		log.Fatal(err)
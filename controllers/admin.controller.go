@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"blogpessoal/database"
+	"blogpessoal/services"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// exportPostagens godoc
+// @Summary Exportar Postagens
+// @Description Exporta todas as Postagens, com as referências ao Tema e ao Usuario, em NDJSON ou CSV
+// @Tags admin
+// @Produce  application/x-ndjson,text/csv
+// @Param formato query string false "Formato da exportação (ndjson ou csv)"
+// @Success 200 {array} services.PostagemTransferencia
+// @Success 400 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Router /admin/export [get]
+// @Security Bearer
+func ExportPostagens(w http.ResponseWriter, r *http.Request) {
+
+	formato := formatoTransferencia(r.URL.Query().Get("formato"), r.Header.Get("Accept"))
+
+	if formato == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Formato Inválido!")
+		return
+	}
+
+	if formato == services.FormatoCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Content-Disposition", "attachment; filename=postagens."+formato)
+	w.WriteHeader(http.StatusOK)
+
+	if err := services.ExportarPostagens(database.Instance, w, formato); err != nil {
+		log.Printf("Erro ao exportar as postagens: %s", err)
+	}
+}
+
+// importPostagens godoc
+// @Summary Importar Postagens
+// @Description Importa Postagens em NDJSON ou CSV, validando cada linha, em uma transação ou no modo parcial. Uma linha atualiza a Postagem com o mesmo id apenas se o slug também for o mesmo; caso contrário, atualiza a Postagem com o mesmo slug, e sem nenhuma das duas cria uma nova. Assim, importar a exportação de outra instância não sobrescreve Postagens locais que só coincidem no id.
+// @Tags admin
+// @Accept  application/x-ndjson,text/csv
+// @Produce  json
+// @Param formato query string false "Formato do arquivo (ndjson ou csv)"
+// @Param modo query string false "transacao (padrão) ou parcial"
+// @Success 200 {object} services.RelatorioImportacao
+// @Success 400 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Success 422 {object} services.RelatorioImportacao
+// @Router /admin/import [post]
+// @Security Bearer
+func ImportPostagens(w http.ResponseWriter, r *http.Request) {
+
+	formato := formatoTransferencia(r.URL.Query().Get("formato"), r.Header.Get("Content-Type"))
+
+	if formato == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Formato Inválido!")
+		return
+	}
+
+	modo := r.URL.Query().Get("modo")
+
+	if modo != "" && modo != "transacao" && modo != "parcial" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Modo Inválido!")
+		return
+	}
+
+	arquivo, err := arquivoEnviado(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Arquivo Inválido!")
+		return
+	}
+	defer arquivo.Close()
+
+	relatorio, err := services.ImportarPostagens(database.Instance, arquivo, formato, modo != "parcial")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if relatorio.Aplicado {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(relatorio)
+}
+
+// formatoTransferencia escolhe o formato pelo parâmetro ou, na falta dele, pelo cabeçalho
+func formatoTransferencia(parametro string, cabecalho string) string {
+
+	switch strings.ToLower(parametro) {
+	case services.FormatoNDJSON, services.FormatoCSV:
+		return strings.ToLower(parametro)
+	case "":
+	default:
+		return ""
+	}
+
+	if strings.Contains(cabecalho, "text/csv") {
+		return services.FormatoCSV
+	}
+	return services.FormatoNDJSON
+}
+
+// arquivoEnviado aceita o arquivo no corpo da requisição ou no campo "arquivo" de um formulário multipart
+func arquivoEnviado(r *http.Request) (io.ReadCloser, error) {
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		arquivo, _, err := r.FormFile("arquivo")
+		return arquivo, err
+	}
+
+	return r.Body, nil
+}
+
+// importWXR godoc
+// @Summary Importar WordPress
+// @Description Importa Usuarios, Temas e Postagens de um arquivo WXR exportado do WordPress
+// @Tags admin
+// @Accept  xml
+// @Produce  json
+// @Param dry_run query bool false "Simula a importação sem gravar nada"
+// @Success 200 {object} services.RelatorioWXR
+// @Success 400 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Router /admin/import/wxr [post]
+// @Security Bearer
+func ImportWXR(w http.ResponseWriter, r *http.Request) {
+
+	arquivo, err := arquivoEnviado(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Arquivo Inválido!")
+		return
+	}
+	defer arquivo.Close()
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	relatorio, err := services.ImportarWXR(database.Instance, arquivo, dryRun)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(relatorio)
+}
@@ -0,0 +1,436 @@
+package controllers
+
+import (
+	"blogpessoal/auth"
+	"blogpessoal/database"
+	"blogpessoal/mail"
+	"blogpessoal/model"
+	"blogpessoal/services"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+)
+
+// Suspensao é o corpo de POST /admin/usuarios/{id}/suspender e /banir
+type Suspensao struct {
+	Motivo string     `json:"motivo" validate:"required,max=500" example:"Spam nos comentários"`
+	Ate    *time.Time `json:"ate,omitempty" example:"2024-01-31T00:00:00Z"`
+}
+
+// AlteracaoPerfil é o corpo de PUT /admin/usuarios/{id}/perfil
+type AlteracaoPerfil struct {
+	Perfil string `json:"perfil" validate:"required,oneof=usuario admin" example:"admin"`
+}
+
+// getAdminUsuarios godoc
+// @Summary Buscar Usuarios
+// @Description Busca os Usuarios pelo nome, e-mail ou slug, filtrando pelo perfil e pela situação da conta
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param busca query string false "Trecho do nome, e-mail ou slug"
+// @Param perfil query string false "usuario ou admin"
+// @Param situacao query string false "ativo, suspenso, banido ou pendente"
+// @Param pagina query int false "Página (padrão 1)"
+// @Param tamanho query int false "Usuarios por página (padrão 20, máximo 100)"
+// @Success 200 {object} services.PaginaUsuarios
+// @Success 403 {object} errorResponse
+// @Router /admin/usuarios [get]
+// @Security Bearer
+func GetAdminUsuarios(w http.ResponseWriter, r *http.Request) {
+
+	pagina, err := strconv.Atoi(r.URL.Query().Get("pagina"))
+	if err != nil || pagina <= 0 {
+		pagina = 1
+	}
+
+	tamanho, err := strconv.Atoi(r.URL.Query().Get("tamanho"))
+	if err != nil || tamanho <= 0 || tamanho > 100 {
+		tamanho = 20
+	}
+
+	usuarios := services.BuscarUsuarios(database.Instance, services.FiltroUsuarios{
+		Busca:    r.URL.Query().Get("busca"),
+		Perfil:   r.URL.Query().Get("perfil"),
+		Situacao: r.URL.Query().Get("situacao"),
+		Pagina:   pagina,
+		Tamanho:  tamanho,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(usuarios)
+}
+
+// getAtividadeUsuario godoc
+// @Summary Atividade do Usuario
+// @Description Mostra a situação da conta, o último acesso, as Postagens, os seguidores e as Notificacoes de um Usuario
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Usuario"
+// @Success 200 {object} services.AtividadeUsuario
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /admin/usuarios/{id}/atividade [get]
+// @Security Bearer
+func GetAtividadeUsuario(w http.ResponseWriter, r *http.Request) {
+
+	usuarioId, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+
+	var usuario model.Usuario
+	database.Instance.Find(&usuario, usuarioId)
+
+	if usuario.ID == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Usuario não encontrado!")
+		return
+	}
+
+	atividade := services.AtividadeDoUsuario(database.Instance, usuario)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(atividade)
+}
+
+// suspendUsuario godoc
+// @Summary Suspender Usuario
+// @Description Bloqueia o acesso do Usuario, mesmo com um token válido, até a data informada ou, sem data, até ele ser reativado
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Usuario"
+// @Param suspensao body controllers.Suspensao true "Motivo e término da suspensão"
+// @Success 200 {object} model.UsuarioAdmin
+// @Success 400 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /admin/usuarios/{id}/suspender [post]
+// @Security Bearer
+func SuspendUsuario(w http.ResponseWriter, r *http.Request) {
+	moderarUsuario(w, r, model.SituacaoSuspenso)
+}
+
+// banUsuario godoc
+// @Summary Banir Usuario
+// @Description Bloqueia o acesso do Usuario, mesmo com um token válido, até ele ser reativado. A data de término é ignorada.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Usuario"
+// @Param banimento body controllers.Suspensao true "Motivo do banimento"
+// @Success 200 {object} model.UsuarioAdmin
+// @Success 400 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /admin/usuarios/{id}/banir [post]
+// @Security Bearer
+func BanUsuario(w http.ResponseWriter, r *http.Request) {
+	moderarUsuario(w, r, model.SituacaoBanido)
+}
+
+func moderarUsuario(w http.ResponseWriter, r *http.Request, situacao string) {
+
+	w.Header().Set("Content-Type", "application/json")
+	var suspensao Suspensao
+	json.NewDecoder(r.Body).Decode(&suspensao)
+
+	validate := validator.New()
+
+	err := validate.Struct(suspensao)
+	if err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		w.WriteHeader(http.StatusBadRequest)
+		responseBody := map[string]string{"error": validationErrors.Error()}
+		if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+			log.Fatalf("Erro: %s", err)
+		}
+		return
+	}
+
+	if situacao == model.SituacaoSuspenso && suspensao.Ate != nil && !suspensao.Ate.After(time.Now()) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("O término da suspensão deve ser uma data futura!")
+		return
+	}
+
+	usuario, ok := usuarioModerado(w, r)
+	if !ok {
+		return
+	}
+
+	if usuario.ID == auth.UsuarioLogado(r).ID {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(services.ErrModerarASiMesmo.Error())
+		return
+	}
+
+	err = services.SuspenderUsuario(database.Instance, usuario.ID, situacao, suspensao.Motivo, suspensao.Ate)
+	if errors.Is(err, services.ErrUsuarioPendente) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível suspender o Usuario!")
+		return
+	}
+
+	responderUsuarioModerado(w, usuario.ID)
+}
+
+// reactivateUsuario godoc
+// @Summary Reativar Usuario
+// @Description Encerra a suspensão ou o banimento do Usuario
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Usuario"
+// @Success 200 {object} model.UsuarioAdmin
+// @Success 400 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /admin/usuarios/{id}/reativar [post]
+// @Security Bearer
+func ReactivateUsuario(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	usuario, ok := usuarioModerado(w, r)
+	if !ok {
+		return
+	}
+
+	err := services.ReativarUsuario(database.Instance, usuario.ID)
+	if errors.Is(err, services.ErrUsuarioPendente) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível reativar o Usuario!")
+		return
+	}
+
+	responderUsuarioModerado(w, usuario.ID)
+}
+
+// changeUsuarioPerfil godoc
+// @Summary Alterar Perfil de acesso
+// @Description Troca o perfil de acesso do Usuario entre usuario e admin. O administrador não pode remover o próprio perfil.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Usuario"
+// @Param perfil body controllers.AlteracaoPerfil true "Novo perfil"
+// @Success 200 {object} model.UsuarioAdmin
+// @Success 400 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /admin/usuarios/{id}/perfil [put]
+// @Security Bearer
+func ChangeUsuarioPerfil(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	var alteracao AlteracaoPerfil
+	json.NewDecoder(r.Body).Decode(&alteracao)
+
+	validate := validator.New()
+
+	err := validate.Struct(alteracao)
+	if err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		w.WriteHeader(http.StatusBadRequest)
+		responseBody := map[string]string{"error": validationErrors.Error()}
+		if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+			log.Fatalf("Erro: %s", err)
+		}
+		return
+	}
+
+	usuario, ok := usuarioModerado(w, r)
+	if !ok {
+		return
+	}
+
+	if usuario.ID == auth.UsuarioLogado(r).ID && alteracao.Perfil != model.PerfilAdmin {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(services.ErrRemoverProprioAdmin.Error())
+		return
+	}
+
+	if err := services.AlterarPerfilUsuario(database.Instance, usuario.ID, alteracao.Perfil); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível alterar o perfil do Usuario!")
+		return
+	}
+
+	responderUsuarioModerado(w, usuario.ID)
+}
+
+// forceRedefinicaoSenha godoc
+// @Summary Forçar redefinição de senha
+// @Description Bloqueia a senha atual do Usuario, encerra todas as sessões dele e envia por e-mail um link de uso único para escolher uma nova senha em /usuarios/senha/redefinir
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Usuario"
+// @Success 200 {object} model.UsuarioAdmin
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Success 502 {object} errorResponse
+// @Router /admin/usuarios/{id}/redefinir-senha [post]
+// @Security Bearer
+func ForceRedefinicaoSenha(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	usuario, ok := usuarioModerado(w, r)
+	if !ok {
+		return
+	}
+
+	err := services.ForcarRedefinicaoSenha(database.Instance, mail.Instance, usuario.ID)
+	if errors.Is(err, services.ErrEnvioRedefinicaoSenha) {
+		log.Printf("Erro ao enviar a redefinição de senha do Usuario %d: %s", usuario.ID, err)
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(services.ErrEnvioRedefinicaoSenha.Error())
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível forçar a redefinição da senha!")
+		return
+	}
+
+	responderUsuarioModerado(w, usuario.ID)
+}
+
+// getCadastrosPendentes godoc
+// @Summary Listar Cadastros Pendentes
+// @Description Lista, dos mais antigos aos mais recentes, os Usuarios cadastrados sem convite que aguardam aprovação
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} model.UsuarioAdmin
+// @Success 403 {object} errorResponse
+// @Router /admin/cadastros/pendentes [get]
+// @Security Bearer
+func GetCadastrosPendentes(w http.ResponseWriter, _ *http.Request) {
+
+	pendentes := services.ListarCadastrosPendentes(database.Instance)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(pendentes)
+}
+
+// approveUsuario godoc
+// @Summary Aprovar Cadastro
+// @Description Ativa a conta de um Usuario que aguarda aprovação
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Usuario"
+// @Success 200 {object} model.UsuarioAdmin
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Success 409 {object} errorResponse
+// @Router /admin/usuarios/{id}/aprovar [post]
+// @Security Bearer
+func ApproveUsuario(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	usuario, ok := usuarioModerado(w, r)
+	if !ok {
+		return
+	}
+
+	err := services.AprovarCadastro(database.Instance, usuario.ID)
+	if errors.Is(err, services.ErrCadastroNaoPendente) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível aprovar o cadastro!")
+		return
+	}
+
+	services.DispararWebhookUsuario(database.Instance, services.EventoUsuarioCriado, usuario)
+	responderUsuarioModerado(w, usuario.ID)
+}
+
+// rejectUsuario godoc
+// @Summary Rejeitar Cadastro
+// @Description Apaga definitivamente a conta de um Usuario que aguarda aprovação
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Usuario"
+// @Success 204 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Success 409 {object} errorResponse
+// @Router /admin/usuarios/{id}/rejeitar [post]
+// @Security Bearer
+func RejectUsuario(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	usuario, ok := usuarioModerado(w, r)
+	if !ok {
+		return
+	}
+
+	err := services.RejeitarCadastro(database.Instance, usuario.ID)
+	if errors.Is(err, services.ErrCadastroNaoPendente) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível rejeitar o cadastro!")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// usuarioModerado carrega o Usuario da rota ou responde 404
+func usuarioModerado(w http.ResponseWriter, r *http.Request) (model.Usuario, bool) {
+
+	usuarioId, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+
+	var usuario model.Usuario
+	database.Instance.Find(&usuario, usuarioId)
+
+	if usuario.ID == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Usuario não encontrado!")
+		return usuario, false
+	}
+
+	return usuario, true
+}
+
+func responderUsuarioModerado(w http.ResponseWriter, usuarioID uint) {
+
+	var usuario model.Usuario
+	database.Instance.First(&usuario, usuarioID)
+
+	admin := usuario.Admin()
+	database.Instance.Model(&model.Postagem{}).Where("usuario_id = ?", usuario.ID).Count(&admin.TotalPostagens)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(admin)
+}
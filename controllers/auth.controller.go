@@ -4,8 +4,10 @@ import (
 	"blogpessoal/auth"
 	"blogpessoal/database"
 	"blogpessoal/model"
+	"blogpessoal/services"
 	"encoding/json"
 	"net/http"
+	"time"
 )
 
 // postUsuario godoc
@@ -26,6 +28,7 @@ func Authetication(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var usuarioLogin model.UsuarioLogin
 	json.NewDecoder(r.Body).Decode(&usuarioLogin)
+	usuarioLogin.Usuario = model.NormalizarEmail(usuarioLogin.Usuario)
 
 	if !checkIfUsuarioEmailExists(usuarioLogin.Usuario){
 		w.WriteHeader(http.StatusUnauthorized)
@@ -35,13 +38,27 @@ func Authetication(w http.ResponseWriter, r *http.Request) {
 	
 	database.Instance.Where("usuario = ?", usuarioLogin.Usuario).Find(&usuario) 
 
-	if !CheckPasswordHash(usuarioLogin.Senha, usuario.Senha){
+	confere, desatualizado := services.ConferirSenha(usuarioLogin.Senha, usuario.Senha)
+	if !confere {
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode("Usuario Inválido!")
 		return
 	}
 
-	token, err = auth.CreateToken(usuarioLogin.Usuario)
+	// Aproveita a senha em texto puro para gravar o hash com o algoritmo e os parâmetros atuais
+	if desatualizado {
+		if hash, err := services.GerarHashSenha(usuarioLogin.Senha); err == nil {
+			database.Instance.Model(&usuario).UpdateColumn("senha", hash)
+		}
+	}
+
+	if usuario.SituacaoAtual() != model.SituacaoAtivo {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(auth.MensagemSituacao(usuario))
+		return
+	}
+
+	token, err = auth.CriarSessao(usuario, usuarioLogin.Dispositivo, r)
 
 	if err != nil{
 		w.WriteHeader(http.StatusUnprocessableEntity)
@@ -53,7 +70,11 @@ func Authetication(w http.ResponseWriter, r *http.Request) {
 	usuarioLogin.Nome = usuario.Nome
 	usuarioLogin.Foto = usuario.Foto
 	usuarioLogin.Senha = ""
+	usuarioLogin.Dispositivo = ""
 	usuarioLogin.Token = "Bearer " + token
+	usuarioLogin.RedefinirSenha = usuario.RedefinirSenha
+
+	database.Instance.Model(&usuario).UpdateColumn("ultimo_login", time.Now())
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(usuarioLogin)
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"blogpessoal/database"
+	"blogpessoal/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// getAutor godoc
+// @Summary Perfil público do autor
+// @Description Mostra o perfil público de um autor, o total de Postagens publicadas, os Temas em que ele escreve e as Postagens publicadas, paginadas. Não exige autenticação.
+// @Tags autores
+// @Accept  json
+// @Produce  json
+// @Param slug path string true "Slug do autor"
+// @Param pagina query int false "Página (padrão 1)"
+// @Param tamanho query int false "Postagens por página (padrão 10, máximo 50)"
+// @Success 200 {object} services.PerfilAutor
+// @Success 404 {object} errorResponse
+// @Router /autores/{slug} [get]
+func GetAutor(w http.ResponseWriter, r *http.Request) {
+
+	pagina, err := strconv.Atoi(r.URL.Query().Get("pagina"))
+	if err != nil || pagina <= 0 {
+		pagina = 1
+	}
+
+	tamanho, err := strconv.Atoi(r.URL.Query().Get("tamanho"))
+	if err != nil || tamanho <= 0 || tamanho > 50 {
+		tamanho = 10
+	}
+
+	perfil, ok := services.PerfilDoAutor(database.Instance, mux.Vars(r)["slug"], pagina, tamanho)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Autor Não Encontrado!")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(perfil)
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"blogpessoal/auth"
+	"blogpessoal/database"
+	"blogpessoal/model"
+	"blogpessoal/services"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ExclusaoConta é a resposta do pedido de exclusão da conta
+type ExclusaoConta struct {
+	ExclusaoAgendadaEm time.Time `json:"exclusao_agendada_em"`
+	Politica           string    `json:"politica" example:"anonimizar"`
+}
+
+// getExportacao godoc
+// @Summary Exportar meus dados
+// @Description Gera um arquivo zip com o perfil, as Postagens (em JSON e em Markdown), os seguidores, as Notificacoes, a preferência de resumo, a atividade e os endereços das mídias do Usuario logado
+// @Tags usuarios
+// @Produce  application/zip
+// @Success 200 {file} file
+// @Router /usuarios/eu/exportacao [get]
+// @Security Bearer
+func GetExportacao(w http.ResponseWriter, r *http.Request) {
+
+	usuario := auth.UsuarioLogado(r)
+
+	// Monta o arquivo inteiro antes de responder, para um erro no meio não entregar um zip corrompido
+	var arquivo bytes.Buffer
+	if err := services.ExportarDadosUsuario(database.Instance, usuario, &arquivo); err != nil {
+		log.Printf("Erro ao exportar os dados do usuario %d: %s", usuario.ID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível exportar os dados!")
+		return
+	}
+
+	nome := fmt.Sprintf("blogpessoal-%s-%s.zip", usuario.Slug, time.Now().Format("20060102"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+nome+`"`)
+	w.Header().Set("Content-Length", strconv.Itoa(arquivo.Len()))
+	w.WriteHeader(http.StatusOK)
+	arquivo.WriteTo(w)
+}
+
+// requestExclusaoConta godoc
+// @Summary Pedir exclusão da conta
+// @Description Agenda a exclusão da conta do Usuario logado, que precisa confirmar a senha. Até a data agendada o pedido pode ser cancelado; depois dela os dados pessoais são apagados e as Postagens publicadas são apagadas ou anonimizadas, conforme a política do blog.
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Param confirmacao body model.ConfirmacaoSenha true "Senha atual"
+// @Success 202 {object} controllers.ExclusaoConta
+// @Success 400 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Success 409 {object} errorResponse
+// @Router /usuarios/eu/exclusao [post]
+// @Security Bearer
+func RequestExclusaoConta(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	var confirmacao model.ConfirmacaoSenha
+	json.NewDecoder(r.Body).Decode(&confirmacao)
+
+	validate := validator.New()
+
+	err := validate.Struct(confirmacao)
+	if err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		w.WriteHeader(http.StatusBadRequest)
+		responseBody := map[string]string{"error": validationErrors.Error()}
+		if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+			log.Fatalf("Erro: %s", err)
+		}
+		return
+	}
+
+	usuario := auth.UsuarioLogado(r)
+
+	if !CheckPasswordHash(confirmacao.Senha, usuario.Senha) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode("Senha Inválida!")
+		return
+	}
+
+	exclusao, err := services.AgendarExclusaoConta(database.Instance, usuario.ID, time.Now())
+	if errors.Is(err, services.ErrExclusaoJaAgendada) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível agendar a exclusão da conta!")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(ExclusaoConta{ExclusaoAgendadaEm: exclusao, Politica: services.PoliticaExclusaoConta})
+}
+
+// cancelExclusaoConta godoc
+// @Summary Cancelar exclusão da conta
+// @Description Desfaz o pedido de exclusão da conta do Usuario logado, enquanto a data agendada não chegou
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} model.UsuarioProprio
+// @Success 404 {object} errorResponse
+// @Router /usuarios/eu/exclusao [delete]
+// @Security Bearer
+func CancelExclusaoConta(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	usuario := auth.UsuarioLogado(r)
+
+	err := services.CancelarExclusaoConta(database.Instance, usuario.ID)
+	if errors.Is(err, services.ErrExclusaoNaoAgendada) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível cancelar a exclusão da conta!")
+		return
+	}
+
+	usuario.ExclusaoAgendadaEm = nil
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(usuario.Proprio())
+}
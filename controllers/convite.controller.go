@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"blogpessoal/auth"
+	"blogpessoal/database"
+	"blogpessoal/model"
+	"blogpessoal/services"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// ConviteRequisicao é o corpo de POST /admin/convites
+type ConviteRequisicao struct {
+	Observacao string     `json:"observacao,omitempty" validate:"max=255" example:"Equipe de marketing"`
+	LimiteUsos int        `json:"limite_usos,omitempty" validate:"omitempty,min=1,max=1000" example:"5"`
+	ExpiraEm   *time.Time `json:"expira_em,omitempty" example:"2024-01-31T00:00:00Z"`
+}
+
+// getModoCadastro godoc
+// @Summary Modo de Cadastro
+// @Description Informa como novos Usuarios podem se cadastrar: aberto, convite, aprovacao ou fechado. Não exige autenticação.
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} map[string]string
+// @Router /usuarios/cadastro [get]
+func GetModoCadastro(w http.ResponseWriter, _ *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"modo": services.ModoCadastro})
+}
+
+// getConvites godoc
+// @Summary Listar Convites
+// @Description Lista os Convites de cadastro, com os usos e a disponibilidade de cada um
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} model.Convite
+// @Success 403 {object} errorResponse
+// @Router /admin/convites [get]
+// @Security Bearer
+func GetConvites(w http.ResponseWriter, _ *http.Request) {
+
+	convites := services.ListarConvites(database.Instance)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(convites)
+}
+
+// createConvite godoc
+// @Summary Criar Convite
+// @Description Gera um Convite de cadastro com código aleatório, limite de usos (padrão 1) e, opcionalmente, data de expiração
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param convite body controllers.ConviteRequisicao true "Criar Convite"
+// @Success 201 {object} model.Convite
+// @Success 400 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Router /admin/convites [post]
+// @Security Bearer
+func CreateConvite(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	var requisicao ConviteRequisicao
+	json.NewDecoder(r.Body).Decode(&requisicao)
+
+	validate := validator.New()
+
+	err := validate.Struct(requisicao)
+	if err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		w.WriteHeader(http.StatusBadRequest)
+		responseBody := map[string]string{"error": validationErrors.Error()}
+		if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+			log.Fatalf("Erro: %s", err)
+		}
+		return
+	}
+
+	if requisicao.ExpiraEm != nil && !requisicao.ExpiraEm.After(time.Now()) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("A expiração do convite deve ser uma data futura!")
+		return
+	}
+
+	criador := auth.UsuarioLogado(r).ID
+	convite, err := services.CriarConvite(database.Instance, model.Convite{
+		Observacao: requisicao.Observacao,
+		LimiteUsos: requisicao.LimiteUsos,
+		ExpiraEm:   requisicao.ExpiraEm,
+		CriadorID:  &criador,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível criar o Convite!")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(convite)
+}
+
+// deleteConvite godoc
+// @Summary Remover Convite
+// @Description Apaga um Convite, que deixa de aceitar cadastros. As contas já criadas com ele não são afetadas.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Convite"
+// @Success 204 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /admin/convites/{id} [delete]
+// @Security Bearer
+func DeleteConvite(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	conviteId, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+
+	err := services.RemoverConvite(database.Instance, uint(conviteId))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Convite não encontrado!")
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível remover o Convite!")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"blogpessoal/auth"
+	"blogpessoal/database"
+	"blogpessoal/model"
+	"blogpessoal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// getLixeira godoc
+// @Summary Listar Lixeira
+// @Description Lista as Postagens apagadas do Usuario logado que ainda podem ser restauradas
+// @Tags lixeira
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} services.Lixeira
+// @Router /lixeira [get]
+// @Security Bearer
+func GetLixeira(w http.ResponseWriter, r *http.Request) {
+
+	lixeira := services.ListarLixeira(database.Instance, auth.UsuarioLogado(r).ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(lixeira)
+}
+
+// getLixeiraAdmin godoc
+// @Summary Listar Lixeira Global
+// @Description Lista todas as Postagens, Temas e Usuarios apagados que ainda podem ser restaurados
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} services.Lixeira
+// @Success 403 {object} errorResponse
+// @Router /admin/lixeira [get]
+// @Security Bearer
+func GetLixeiraAdmin(w http.ResponseWriter, _ *http.Request) {
+
+	lixeira := services.ListarLixeira(database.Instance, 0)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(lixeira)
+}
+
+// restorePostagem godoc
+// @Summary Restaurar Postagem
+// @Description Tira uma Postagem da lixeira. Apenas o autor ou um administrador podem restaurá-la.
+// @Tags lixeira
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id da Postagem"
+// @Success 200 {object} model.PostagemResposta
+// @Success 404 {object} errorResponse
+// @Success 409 {object} errorResponse
+// @Router /lixeira/postagens/{id}/restaurar [post]
+// @Security Bearer
+func RestorePostagem(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	postagemId, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+
+	usuario := auth.UsuarioLogado(r)
+	postagem, ok := services.PostagemNaLixeira(database.Instance, uint(postagemId))
+
+	if !ok || (postagem.UsuarioID != usuario.ID && usuario.Perfil != model.PerfilAdmin) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Postagem Não Encontrada!")
+		return
+	}
+
+	err := services.RestaurarPostagem(database.Instance, postagem)
+	if errors.Is(err, services.ErrRestauracaoTema) || errors.Is(err, services.ErrRestauracaoUsuario) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível restaurar a Postagem!")
+		return
+	}
+
+	database.Instance.Joins("Tema").Joins("Usuario").First(&postagem, postagem.ID)
+	postagem.Breadcrumbs = breadcrumbsPostagem(postagem)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(postagem.Resposta())
+}
+
+// restoreTema godoc
+// @Summary Restaurar Tema
+// @Description Tira um Tema da lixeira
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Tema"
+// @Success 200 {object} model.TemaResposta
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /admin/lixeira/temas/{id}/restaurar [post]
+// @Security Bearer
+func RestoreTema(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	temaId, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+
+	err := services.RestaurarTema(database.Instance, uint(temaId))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Tema Não Encontrado!")
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível restaurar o Tema!")
+		return
+	}
+
+	var tema model.Tema
+
+	database.Instance.First(&tema, temaId)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tema.Resposta())
+}
+
+// restoreUsuario godoc
+// @Summary Restaurar Usuario
+// @Description Tira um Usuario da lixeira
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Usuario"
+// @Success 200 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /admin/lixeira/usuarios/{id}/restaurar [post]
+// @Security Bearer
+func RestoreUsuario(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	usuarioId, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+
+	err := services.RestaurarUsuario(database.Instance, uint(usuarioId))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Usuario não encontrado!")
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível restaurar o Usuario!")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode("Usuario Restaurado!")
+}
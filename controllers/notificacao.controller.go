@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"blogpessoal/auth"
+	"blogpessoal/database"
+	"blogpessoal/model"
+	"blogpessoal/services"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Intervalo dos comentários enviados pelo stream para manter a conexão aberta em proxies
+const intervaloHeartbeat = 25 * time.Second
+
+// getNotificacoes godoc
+// @Summary Listar Notificacoes
+// @Description Lista as Notificacoes do Usuario logado, das mais recentes às mais antigas, com o total de não lidas
+// @Tags notificacoes
+// @Accept  json
+// @Produce  json
+// @Param nao_lidas query bool false "Lista apenas as não lidas"
+// @Param pagina query int false "Página (padrão 1)"
+// @Param tamanho query int false "Notificacoes por página (padrão 20, máximo 100)"
+// @Success 200 {object} services.PaginaNotificacoes
+// @Router /notificacoes [get]
+// @Security Bearer
+func GetNotificacoes(w http.ResponseWriter, r *http.Request) {
+
+	pagina, err := strconv.Atoi(r.URL.Query().Get("pagina"))
+	if err != nil || pagina <= 0 {
+		pagina = 1
+	}
+
+	tamanho, err := strconv.Atoi(r.URL.Query().Get("tamanho"))
+	if err != nil || tamanho <= 0 || tamanho > 100 {
+		tamanho = 20
+	}
+
+	notificacoes := services.ListarNotificacoes(database.Instance, auth.UsuarioLogado(r).ID, r.URL.Query().Get("nao_lidas") == "true", pagina, tamanho)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(notificacoes)
+}
+
+// getNotificacoesNaoLidas godoc
+// @Summary Contar Notificacoes Não Lidas
+// @Description Retorna quantas Notificacoes do Usuario logado ainda não foram lidas
+// @Tags notificacoes
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} map[string]int64
+// @Router /notificacoes/nao-lidas [get]
+// @Security Bearer
+func GetNotificacoesNaoLidas(w http.ResponseWriter, r *http.Request) {
+
+	total := services.TotalNaoLidas(database.Instance, auth.UsuarioLogado(r).ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]int64{"nao_lidas": total})
+}
+
+// markNotificacaoLida godoc
+// @Summary Marcar Notificacao como Lida
+// @Description Marca como lida uma Notificacao do Usuario logado
+// @Tags notificacoes
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id da Notificacao"
+// @Success 204 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /notificacoes/{id}/lida [post]
+// @Security Bearer
+func MarkNotificacaoLida(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Notificacao Não Encontrada!")
+		return
+	}
+
+	usuario := auth.UsuarioLogado(r)
+
+	var total int64
+	database.Instance.Model(&model.Notificacao{}).Where("id = ? AND usuario_id = ?", id, usuario.ID).Count(&total)
+
+	if total == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Notificacao Não Encontrada!")
+		return
+	}
+
+	if _, err := services.MarcarComoLidas(database.Instance, usuario.ID, uint(id)); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível marcar a Notificacao como lida!")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// markNotificacoesLidas godoc
+// @Summary Marcar Todas as Notificacoes como Lidas
+// @Description Marca como lidas todas as Notificacoes do Usuario logado
+// @Tags notificacoes
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} map[string]int64
+// @Router /notificacoes/lidas [post]
+// @Security Bearer
+func MarkNotificacoesLidas(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	total, err := services.MarcarComoLidas(database.Instance, auth.UsuarioLogado(r).ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível marcar as Notificacoes como lidas!")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]int64{"marcadas": total})
+}
+
+// streamNotificacoes godoc
+// @Summary Stream de Notificacoes
+// @Description Mantém a conexão aberta e envia, por Server-Sent Events, cada nova Notificacao do Usuario logado. O token pode ser informado no parâmetro token, já que o EventSource dos navegadores não envia cabeçalhos.
+// @Tags notificacoes
+// @Produce  text/event-stream
+// @Param token query string false "Token JWT"
+// @Success 200 {object} model.Notificacao
+// @Router /notificacoes/stream [get]
+// @Security Bearer
+func StreamNotificacoes(w http.ResponseWriter, r *http.Request) {
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Streaming não suportado!")
+		return
+	}
+
+	usuario := auth.UsuarioLogado(r)
+	notificacoes, cancelar := services.Notificacoes.Assinar(usuario.ID)
+	defer cancelar()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "event: nao_lidas\ndata: %d\n\n", services.TotalNaoLidas(database.Instance, usuario.ID))
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(intervaloHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+
+		case notificacao := <-notificacoes:
+			dados, _ := json.Marshal(notificacao)
+			fmt.Fprintf(w, "id: %d\nevent: notificacao\ndata: %s\n\n", notificacao.ID, dados)
+			flusher.Flush()
+		}
+	}
+}
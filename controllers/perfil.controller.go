@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"blogpessoal/auth"
+	"blogpessoal/database"
+	"blogpessoal/model"
+	"blogpessoal/services"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// getPerfil godoc
+// @Summary Perfil do Usuario logado
+// @Description Retorna o perfil do Usuario logado, com as Postagens dele
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} model.UsuarioProprio
+// @Router /usuarios/eu [get]
+// @Security Bearer
+func GetPerfil(w http.ResponseWriter, r *http.Request) {
+
+	var usuario model.Usuario
+
+	database.Instance.Preload("Postagens").First(&usuario, auth.UsuarioLogado(r).ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(usuario.Proprio())
+}
+
+// putPerfil godoc
+// @Summary Atualizar Perfil
+// @Description Edita o perfil público do Usuario logado: nome, foto, slug, apresentação, site e links. Sem slug, o atual é mantido.
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Param perfil body model.PerfilAtualizacao true "Atualizar Perfil"
+// @Success 200 {object} model.UsuarioProprio
+// @Success 400 {object} errorResponse
+// @Router /usuarios/eu [put]
+// @Security Bearer
+func UpdatePerfil(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	var atualizacao model.PerfilAtualizacao
+	json.NewDecoder(r.Body).Decode(&atualizacao)
+
+	validate := validator.New()
+
+	err := validate.Struct(atualizacao)
+	if err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		w.WriteHeader(http.StatusBadRequest)
+		responseBody := map[string]string{"error": validationErrors.Error()}
+		if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+			log.Fatalf("Erro: %s", err)
+		}
+		return
+	}
+
+	usuario := auth.UsuarioLogado(r)
+	usuario.Nome = atualizacao.Nome
+	usuario.Foto = atualizacao.Foto
+	usuario.Sobre = atualizacao.Sobre
+	usuario.Site = atualizacao.Site
+	usuario.Links = atualizacao.Links
+	if atualizacao.Slug != "" {
+		usuario.Slug = atualizacao.Slug
+	}
+
+	err = database.Instance.Model(&usuario).Select("nome", "foto", "slug", "sobre", "site", "links").Updates(&usuario).Error
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível salvar o Perfil!")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(usuario.Proprio())
+}
+
+// putSenha godoc
+// @Summary Alterar Senha
+// @Description Troca a senha do Usuario logado, que precisa informar a senha atual. Todas as Sessoes são encerradas e os tokens emitidos antes da troca deixam de valer; a resposta traz o token de uma nova Sessao no dispositivo atual.
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Param senha body model.AlteracaoSenha true "Alterar Senha"
+// @Success 200 {object} model.UsuarioLogin
+// @Success 400 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Router /usuarios/eu/senha [put]
+// @Security Bearer
+func UpdateSenha(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	var alteracao model.AlteracaoSenha
+	json.NewDecoder(r.Body).Decode(&alteracao)
+
+	validate := validator.New()
+
+	err := validate.Struct(alteracao)
+	if err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		w.WriteHeader(http.StatusBadRequest)
+		responseBody := map[string]string{"error": validationErrors.Error()}
+		if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+			log.Fatalf("Erro: %s", err)
+		}
+		return
+	}
+
+	usuario := auth.UsuarioLogado(r)
+
+	if !CheckPasswordHash(alteracao.SenhaAtual, usuario.Senha) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode("Senha Atual Inválida!")
+		return
+	}
+
+	if alteracao.NovaSenha == alteracao.SenhaAtual {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(services.ErrSenhaNaoAlterada.Error())
+		return
+	}
+
+	if err := services.ValidarSenha(alteracao.NovaSenha, usuario); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+
+	hash, _ := HashPassword(alteracao.NovaSenha)
+
+	// Incrementar a versão invalida os tokens de todas as sessões abertas
+	err = database.Instance.Model(&usuario).Updates(map[string]interface{}{"senha": hash, "redefinir_senha": false, "versao_token": gorm.Expr("versao_token + 1")}).Error
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível alterar a Senha!")
+		return
+	}
+
+	database.Instance.Select("versao_token").First(&usuario, usuario.ID)
+
+	// As Sessoes antigas são encerradas e a atual continua em uma nova Sessao no mesmo dispositivo
+	services.EncerrarSessoes(database.Instance, usuario.ID)
+	token, err := auth.CriarSessao(usuario, auth.SessaoAtual(r).Dispositivo, r)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível gerar o token!")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(model.UsuarioLogin{
+		ID:      usuario.ID,
+		Nome:    usuario.Nome,
+		Usuario: usuario.Usuario,
+		Foto:    usuario.Foto,
+		Token:   "Bearer " + token,
+	})
+}
@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"blogpessoal/auth"
 	"blogpessoal/database"
 	"blogpessoal/model"
+	"blogpessoal/services"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -21,30 +23,31 @@ import (
 
 // getAll godoc
 // @Summary Listar Postagens
-// @Description Lista todas as Postagens
+// @Description Lista as Postagens publicadas e os rascunhos do Usuario logado. Administradores veem todos os rascunhos.
 // @Tags postagens
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} model.Postagem
+// @Success 200 {array} model.PostagemResposta
 // @Router /postagens [get]
 // @Security Bearer
-func GetPostagens(w http.ResponseWriter, _ *http.Request) {
+func GetPostagens(w http.ResponseWriter, r *http.Request) {
 	var postagens []model.Postagem
 
-	database.Instance.Joins("Tema").Joins("Usuario").Find(&postagens)
+	database.Instance.Joins("Tema").Joins("Usuario").Scopes(services.PostagensVisiveis(auth.UsuarioLogado(r))).Find(&postagens)
+	services.PreencherBreadcrumbs(database.Instance, postagens)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(postagens)
+	json.NewEncoder(w).Encode(model.PostagensResposta(postagens))
 }
 
 // getById godoc
 // @Summary Listar Postagem por id
-// @Description Lista uma Postagem por id
+// @Description Lista uma Postagem por id. Rascunhos só são vistos pelo autor e pelos administradores.
 // @Tags postagens
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Id da Postagem"
-// @Success 200 {array} model.Postagem
+// @Success 200 {object} model.PostagemResposta
 // @Success 400 {object} errorResponse
 // @Success 404 {object} errorResponse
 // @Success 405 {object} errorResponse
@@ -63,19 +66,27 @@ func GetPostagemById(w http.ResponseWriter, r *http.Request) {
 	var postagem model.Postagem
 
 	database.Instance.Joins("Tema").Joins("Usuario").First(&postagem, postagemId)
+
+	if !services.PostagemVisivel(auth.UsuarioLogado(r), postagem) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Postagem Não Encontrada!")
+		return
+	}
+
+	postagem.Breadcrumbs = breadcrumbsPostagem(postagem)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(postagem)
+	json.NewEncoder(w).Encode(postagem.Resposta())
 }
 
 // getByTitulo godoc
 // @Summary Listar Postagens por título
-// @Description Lista todas as Postagem por título
+// @Description Lista as Postagens visíveis ao Usuario logado cujo título contém o texto informado
 // @Tags postagens
 // @Accept  json
 // @Produce  json
 // @Param titulo path string true "Título da Postagem"
-// @Success 200 {array} model.Postagem
+// @Success 200 {array} model.PostagemResposta
 // @Success 400 {object} errorResponse
 // @Success 405 {object} errorResponse
 // @Router /postagens/titulo/{titulo} [get]
@@ -86,10 +97,11 @@ func GetPostagemByTitulo(w http.ResponseWriter, r *http.Request) {
 
 	var postagens []model.Postagem
 
-	database.Instance.Joins("Tema").Joins("Usuario").Where("titulo LIKE ?", "%"+postagemTitulo+"%").Find(&postagens)
+	database.Instance.Joins("Tema").Joins("Usuario").Scopes(services.PostagensVisiveis(auth.UsuarioLogado(r))).Where("titulo LIKE ?", "%"+postagemTitulo+"%").Find(&postagens)
+	services.PreencherBreadcrumbs(database.Instance, postagens)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(postagens)
+	json.NewEncoder(w).Encode(model.PostagensResposta(postagens))
 }
 
 // postPostagem godoc
@@ -98,19 +110,19 @@ func GetPostagemByTitulo(w http.ResponseWriter, r *http.Request) {
 // @Tags postagens
 // @Accept  json
 // @Produce  json
-// @Param postagem body model.Postagem true "Criar Postagem"
-// @Success 201 {object} model.Postagem
+// @Param postagem body model.PostagemRequisicao true "Criar Postagem"
+// @Success 201 {object} model.PostagemResposta
 // @Router /postagens [post]
 // @Security Bearer
 func CreatePostagem(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
-	var postagem model.Postagem
-	json.NewDecoder(r.Body).Decode(&postagem)
+	var requisicao model.PostagemRequisicao
+	json.NewDecoder(r.Body).Decode(&requisicao)
 
 	validate := validator.New()
 
-	err := validate.Struct(postagem)
+	err := validate.Struct(requisicao)
 
 	if err != nil {
 		validationErrors := err.(validator.ValidationErrors)
@@ -123,7 +135,7 @@ func CreatePostagem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var temaId string = strconv.FormatUint(uint64(postagem.TemaID), 10)
+	var temaId string = strconv.FormatUint(uint64(requisicao.TemaID), 10)
 
 	if !checkIfTemaExists(temaId) {
 		w.WriteHeader(http.StatusNotFound)
@@ -131,9 +143,13 @@ func CreatePostagem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	postagem := requisicao.Modelo()
 	database.Instance.Create(&postagem)
+	services.NotificarNovaPostagem(database.Instance, postagem)
+	services.DispararWebhookPostagem(database.Instance, services.EventoPostagemCriada, postagem)
+
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(postagem)
+	json.NewEncoder(w).Encode(postagem.Resposta())
 }
 
 // putPostagem godoc
@@ -143,8 +159,8 @@ func CreatePostagem(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Id da Postagem"
-// @Param postagem body model.Postagem true "Atualizar Postagem"
-// @Success 200 {object} model.Postagem
+// @Param postagem body model.PostagemRequisicao true "Atualizar Postagem"
+// @Success 200 {object} model.PostagemResposta
 // @Success 400 {object} errorResponse
 // @Success 404 {object} errorResponse
 // @Success 405 {object} errorResponse
@@ -152,12 +168,12 @@ func CreatePostagem(w http.ResponseWriter, r *http.Request) {
 // @Security Bearer
 func UpdatePostagem(w http.ResponseWriter, r *http.Request) {
 
-	var postagem model.Postagem
-	json.NewDecoder(r.Body).Decode(&postagem)
+	var requisicao model.PostagemRequisicao
+	json.NewDecoder(r.Body).Decode(&requisicao)
 
 	validate := validator.New()
 
-	err := validate.Struct(postagem)
+	err := validate.Struct(requisicao)
 
 	if err != nil {
 		validationErrors := err.(validator.ValidationErrors)
@@ -170,7 +186,7 @@ func UpdatePostagem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var id = strconv.FormatUint(uint64(postagem.ID), 10)
+	var id = strconv.FormatUint(uint64(requisicao.ID), 10)
 
 	if !checkIfPostagemExists(id) {
 		w.WriteHeader(http.StatusNotFound)
@@ -178,7 +194,7 @@ func UpdatePostagem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var temaId string = strconv.FormatUint(uint64(postagem.TemaID), 10)
+	var temaId string = strconv.FormatUint(uint64(requisicao.TemaID), 10)
 
 	if !checkIfTemaExists(temaId) {
 		w.WriteHeader(http.StatusNotFound)
@@ -186,10 +202,21 @@ func UpdatePostagem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	postagem := requisicao.Modelo()
+
+	var anterior model.Postagem
+	database.Instance.Select("status").First(&anterior, postagem.ID)
+
 	database.Instance.Save(&postagem)
+
+	if anterior.Status != model.StatusPublicado {
+		services.NotificarNovaPostagem(database.Instance, postagem)
+	}
+	services.DispararWebhookPostagem(database.Instance, services.EventoPostagemAtualizada, postagem)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(postagem)
+	json.NewEncoder(w).Encode(postagem.Resposta())
 }
 
 // deletePostagem godoc
@@ -218,11 +245,21 @@ func DeletePostagem(w http.ResponseWriter, r *http.Request) {
 
 	var postagem model.Postagem
 
-	database.Instance.Delete(&postagem, postagemId)
+	database.Instance.First(&postagem, postagemId)
+	database.Instance.Delete(&postagem)
+	services.DispararWebhookPostagem(database.Instance, services.EventoPostagemRemovida, postagem)
 	w.WriteHeader(http.StatusNoContent)
 	json.NewEncoder(w).Encode("Postagem Deletada!")
 }
 
+func breadcrumbsPostagem(postagem model.Postagem) []model.TemaResumo {
+
+	postagens := []model.Postagem{postagem}
+	services.PreencherBreadcrumbs(database.Instance, postagens)
+
+	return postagens[0].Breadcrumbs
+}
+
 func checkIfPostagemExists(postagemId string) bool {
 
 	var postagem model.Postagem
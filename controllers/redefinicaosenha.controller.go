@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"blogpessoal/database"
+	"blogpessoal/model"
+	"blogpessoal/services"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// getRedefinicaoSenha godoc
+// @Summary Página de Redefinição de Senha
+// @Description Página aberta pelo link enviado por e-mail quando um administrador força a redefinição da senha. Mostra o formulário da nova senha. Não exige login.
+// @Tags usuarios
+// @Produce  html
+// @Param token query string true "Token do link de redefinição"
+// @Success 200 {string} string
+// @Router /usuarios/senha/redefinir [get]
+func GetRedefinicaoSenha(w http.ResponseWriter, r *http.Request) {
+
+	responderPaginaRedefinicao(w, http.StatusOK, paginaRedefinicao{Token: r.URL.Query().Get("token")})
+}
+
+// redefineSenha godoc
+// @Summary Redefinir Senha
+// @Description Troca a senha com o token de uso único enviado por e-mail. A senha antiga não é pedida nem aceita. Aceita JSON ou o formulário da página de redefinição. Não exige login.
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Param redefinicao body model.RedefinicaoSenha true "Redefinir Senha"
+// @Success 200 {object} errorResponse
+// @Success 400 {object} errorResponse
+// @Router /usuarios/senha/redefinir [post]
+func RedefineSenha(w http.ResponseWriter, r *http.Request) {
+
+	// O formulário da página recebe a resposta em HTML; os clientes da API, em JSON
+	pagina := strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+
+	var redefinicao model.RedefinicaoSenha
+	if pagina {
+		redefinicao.Token = r.URL.Query().Get("token")
+		redefinicao.NovaSenha = r.PostFormValue("nova_senha")
+	} else {
+		json.NewDecoder(r.Body).Decode(&redefinicao)
+	}
+
+	validate := validator.New()
+
+	err := validate.Struct(redefinicao)
+	if err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		if pagina {
+			responderPaginaRedefinicao(w, http.StatusBadRequest, paginaRedefinicao{Mensagem: "Informe a nova senha!", Token: redefinicao.Token})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		responseBody := map[string]string{"error": validationErrors.Error()}
+		if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+			log.Fatalf("Erro: %s", err)
+		}
+		return
+	}
+
+	usuario, err := services.UsuarioDoTokenRedefinicao(database.Instance, redefinicao.Token)
+	if err != nil {
+		responderRedefinicao(w, pagina, http.StatusBadRequest, err.Error(), "")
+		return
+	}
+
+	// Uma senha recusada pela política não consome o link
+	if err := services.ValidarSenha(redefinicao.NovaSenha, usuario); err != nil {
+		responderRedefinicao(w, pagina, http.StatusBadRequest, err.Error(), redefinicao.Token)
+		return
+	}
+
+	hash, _ := HashPassword(redefinicao.NovaSenha)
+
+	err = services.RedefinirSenhaComToken(database.Instance, usuario, hash)
+	if errors.Is(err, services.ErrTokenRedefinicaoInvalido) {
+		responderRedefinicao(w, pagina, http.StatusBadRequest, err.Error(), "")
+		return
+	}
+	if err != nil {
+		responderRedefinicao(w, pagina, http.StatusInternalServerError, "Não foi possível redefinir a Senha!", "")
+		return
+	}
+
+	responderRedefinicao(w, pagina, http.StatusOK, "Senha redefinida! Entre novamente com a nova senha.", "")
+}
+
+func responderRedefinicao(w http.ResponseWriter, pagina bool, status int, mensagem string, token string) {
+
+	if pagina {
+		responderPaginaRedefinicao(w, status, paginaRedefinicao{Mensagem: mensagem, Token: token})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(mensagem)
+}
+
+type paginaRedefinicao struct {
+	Mensagem string
+	// Token do link; quando informado, a página mostra o formulário da nova senha
+	Token string
+}
+
+func responderPaginaRedefinicao(w http.ResponseWriter, status int, dados paginaRedefinicao) {
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := templatePaginaRedefinicao.Execute(w, dados); err != nil {
+		log.Printf("Erro ao exibir a página de redefinição de senha: %s", err)
+	}
+}
+
+var templatePaginaRedefinicao = template.Must(template.New("redefinir").Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head><meta charset="utf-8"><title>Redefinir senha</title></head>
+<body style="font-family: sans-serif; max-width: 600px; margin: auto">
+{{if .Mensagem}}<p>{{.Mensagem}}</p>
+{{end}}{{if .Token}}<form method="post" action="?token={{.Token}}">
+<p><label>Nova senha <input type="password" name="nova_senha" autocomplete="new-password" required></label></p>
+<button type="submit">Redefinir a senha</button>
+</form>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"blogpessoal/auth"
+	"blogpessoal/database"
+	"blogpessoal/model"
+	"blogpessoal/services"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// getPreferenciaResumo godoc
+// @Summary Preferência do Resumo
+// @Description Retorna com que frequência o Usuario logado recebe o resumo por e-mail das novas Postagens de quem ele segue
+// @Tags resumos
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} model.AssinaturaResumo
+// @Router /resumos/preferencias [get]
+// @Security Bearer
+func GetPreferenciaResumo(w http.ResponseWriter, r *http.Request) {
+
+	assinatura, err := services.AssinaturaDoUsuario(database.Instance, auth.UsuarioLogado(r).ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível carregar a preferência!")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(assinatura)
+}
+
+// putPreferenciaResumo godoc
+// @Summary Atualizar Preferência do Resumo
+// @Description Altera a frequência do resumo por e-mail do Usuario logado: nunca, diaria ou semanal
+// @Tags resumos
+// @Accept  json
+// @Produce  json
+// @Param preferencia body model.AssinaturaResumo true "Frequência do resumo"
+// @Success 200 {object} model.AssinaturaResumo
+// @Success 400 {object} errorResponse
+// @Router /resumos/preferencias [put]
+// @Security Bearer
+func UpdatePreferenciaResumo(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	var preferencia model.AssinaturaResumo
+	json.NewDecoder(r.Body).Decode(&preferencia)
+
+	validate := validator.New()
+
+	err := validate.StructPartial(preferencia, "Frequencia")
+	if err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		w.WriteHeader(http.StatusBadRequest)
+		responseBody := map[string]string{"error": validationErrors.Error()}
+		if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+			log.Fatalf("Erro: %s", err)
+		}
+		return
+	}
+
+	assinatura, err := services.AlterarFrequenciaResumo(database.Instance, auth.UsuarioLogado(r).ID, preferencia.Frequencia)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível salvar a preferência!")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(assinatura)
+}
+
+// getCancelResumo godoc
+// @Summary Confirmar Cancelamento do Resumo
+// @Description Página aberta pelo link de cancelamento do resumo por e-mail. Apenas pede a confirmação, para que antivírus e leitores de e-mail que visitam os links não cancelem o resumo.
+// @Tags resumos
+// @Produce  html
+// @Param token query string true "Token do link de cancelamento"
+// @Success 200 {string} string
+// @Router /resumos/cancelar [get]
+func GetCancelResumo(w http.ResponseWriter, r *http.Request) {
+
+	responderPaginaResumo(w, http.StatusOK, paginaResumo{
+		Mensagem: "Deseja deixar de receber o resumo por e-mail?",
+		Token:    r.URL.Query().Get("token"),
+	})
+}
+
+// cancelResumo godoc
+// @Summary Cancelar Resumo
+// @Description Cancela o resumo por e-mail. Atende o cancelamento com um clique dos provedores de e-mail (RFC 8058) e o formulário da página de confirmação. Não exige login.
+// @Tags resumos
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Param token query string true "Token do link de cancelamento"
+// @Success 200 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /resumos/cancelar [post]
+func CancelResumo(w http.ResponseWriter, r *http.Request) {
+
+	// O formulário da página de confirmação recebe a resposta em HTML; os provedores de e-mail, em JSON
+	pagina := strings.Contains(r.Header.Get("Accept"), "text/html")
+
+	err := services.CancelarResumo(database.Instance, r.URL.Query().Get("token"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		responderCancelamentoResumo(w, pagina, http.StatusNotFound, "Link de cancelamento inválido!")
+		return
+	}
+	if err != nil {
+		responderCancelamentoResumo(w, pagina, http.StatusInternalServerError, "Não foi possível cancelar o resumo!")
+		return
+	}
+
+	responderCancelamentoResumo(w, pagina, http.StatusOK, "Você não receberá mais o resumo por e-mail.")
+}
+
+func responderCancelamentoResumo(w http.ResponseWriter, pagina bool, status int, mensagem string) {
+
+	if pagina {
+		responderPaginaResumo(w, status, paginaResumo{Mensagem: mensagem})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(mensagem)
+}
+
+type paginaResumo struct {
+	Mensagem string
+	// Token do link de cancelamento; quando informado, a página mostra o botão de confirmação
+	Token string
+}
+
+func responderPaginaResumo(w http.ResponseWriter, status int, dados paginaResumo) {
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := templatePaginaResumo.Execute(w, dados); err != nil {
+		log.Printf("Erro ao exibir a página do resumo: %s", err)
+	}
+}
+
+var templatePaginaResumo = template.Must(template.New("cancelar").Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head><meta charset="utf-8"><title>Resumo por e-mail</title></head>
+<body style="font-family: sans-serif; max-width: 600px; margin: auto">
+<p>{{.Mensagem}}</p>
+{{if .Token}}<form method="post" action="?token={{.Token}}">
+<button type="submit">Cancelar o resumo</button>
+</form>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"blogpessoal/auth"
+	"blogpessoal/database"
+	"blogpessoal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// followUsuario godoc
+// @Summary Seguir Usuario
+// @Description O Usuario logado passa a seguir o Usuario informado
+// @Tags seguidores
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Usuario"
+// @Success 204 {object} errorResponse
+// @Success 400 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /usuarios/{id}/seguir [post]
+// @Security Bearer
+func FollowUsuario(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	usuarioId := mux.Vars(r)["id"]
+
+	if !checkIfUsuarioExists(usuarioId) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Usuario não encontrado!")
+		return
+	}
+
+	id, _ := strconv.ParseUint(usuarioId, 10, 32)
+	seguidor := auth.UsuarioLogado(r)
+
+	novo, err := services.SeguirUsuario(database.Instance, seguidor.ID, uint(id))
+	if errors.Is(err, services.ErrSeguirASiMesmo) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível seguir o Usuario!")
+		return
+	}
+
+	if novo {
+		services.NotificarNovoSeguidor(database.Instance, seguidor, uint(id))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unfollowUsuario godoc
+// @Summary Deixar de Seguir Usuario
+// @Description O Usuario logado deixa de seguir o Usuario informado
+// @Tags seguidores
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Usuario"
+// @Success 204 {object} errorResponse
+// @Router /usuarios/{id}/seguir [delete]
+// @Security Bearer
+func UnfollowUsuario(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	id, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+
+	if err := services.DeixarDeSeguirUsuario(database.Instance, auth.UsuarioLogado(r).ID, uint(id)); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível deixar de seguir o Usuario!")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getSeguidoresUsuario godoc
+// @Summary Listar Seguidores do Usuario
+// @Description Lista quem segue o Usuario, com o total
+// @Tags seguidores
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Usuario"
+// @Success 200 {object} services.Seguidores
+// @Success 404 {object} errorResponse
+// @Router /usuarios/{id}/seguidores [get]
+// @Security Bearer
+func GetSeguidoresUsuario(w http.ResponseWriter, r *http.Request) {
+
+	usuarioId := mux.Vars(r)["id"]
+
+	if !checkIfUsuarioExists(usuarioId) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Usuario não encontrado!")
+		return
+	}
+
+	id, _ := strconv.ParseUint(usuarioId, 10, 32)
+
+	seguidores := services.SeguidoresUsuario(database.Instance, uint(id))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(seguidores)
+}
+
+// getSeguindoUsuario godoc
+// @Summary Listar quem o Usuario Segue
+// @Description Lista os Usuarios e os Temas seguidos pelo Usuario, com os totais
+// @Tags seguidores
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Usuario"
+// @Success 200 {object} services.Seguindo
+// @Success 404 {object} errorResponse
+// @Router /usuarios/{id}/seguindo [get]
+// @Security Bearer
+func GetSeguindoUsuario(w http.ResponseWriter, r *http.Request) {
+
+	usuarioId := mux.Vars(r)["id"]
+
+	if !checkIfUsuarioExists(usuarioId) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Usuario não encontrado!")
+		return
+	}
+
+	id, _ := strconv.ParseUint(usuarioId, 10, 32)
+
+	seguindo := services.SeguidosPorUsuario(database.Instance, uint(id))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(seguindo)
+}
+
+// followTema godoc
+// @Summary Seguir Tema
+// @Description O Usuario logado passa a seguir o Tema informado
+// @Tags seguidores
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Tema"
+// @Success 204 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /temas/{id}/seguir [post]
+// @Security Bearer
+func FollowTema(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	temaId := mux.Vars(r)["id"]
+
+	if !checkIfTemaExists(temaId) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Tema Não Encontrado!")
+		return
+	}
+
+	id, _ := strconv.ParseUint(temaId, 10, 32)
+
+	if err := services.SeguirTema(database.Instance, auth.UsuarioLogado(r).ID, uint(id)); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível seguir o Tema!")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unfollowTema godoc
+// @Summary Deixar de Seguir Tema
+// @Description O Usuario logado deixa de seguir o Tema informado
+// @Tags seguidores
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Tema"
+// @Success 204 {object} errorResponse
+// @Router /temas/{id}/seguir [delete]
+// @Security Bearer
+func UnfollowTema(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	id, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+
+	if err := services.DeixarDeSeguirTema(database.Instance, auth.UsuarioLogado(r).ID, uint(id)); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível deixar de seguir o Tema!")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getSeguidoresTema godoc
+// @Summary Listar Seguidores do Tema
+// @Description Lista quem segue o Tema, com o total
+// @Tags seguidores
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Tema"
+// @Success 200 {object} services.Seguidores
+// @Success 404 {object} errorResponse
+// @Router /temas/{id}/seguidores [get]
+// @Security Bearer
+func GetSeguidoresTema(w http.ResponseWriter, r *http.Request) {
+
+	temaId := mux.Vars(r)["id"]
+
+	if !checkIfTemaExists(temaId) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Tema Não Encontrado!")
+		return
+	}
+
+	id, _ := strconv.ParseUint(temaId, 10, 32)
+
+	seguidores := services.SeguidoresTema(database.Instance, uint(id))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(seguidores)
+}
+
+// getFeed godoc
+// @Summary Feed
+// @Description Lista, das mais recentes às mais antigas, as Postagens publicadas pelos Usuarios e nos Temas que o Usuario logado segue
+// @Tags seguidores
+// @Accept  json
+// @Produce  json
+// @Param pagina query int false "Página (padrão 1)"
+// @Param tamanho query int false "Postagens por página (padrão 10, máximo 50)"
+// @Success 200 {object} services.PaginaFeed
+// @Router /feed [get]
+// @Security Bearer
+func GetFeed(w http.ResponseWriter, r *http.Request) {
+
+	pagina, err := strconv.Atoi(r.URL.Query().Get("pagina"))
+	if err != nil || pagina <= 0 {
+		pagina = 1
+	}
+
+	tamanho, err := strconv.Atoi(r.URL.Query().Get("tamanho"))
+	if err != nil || tamanho <= 0 || tamanho > 50 {
+		tamanho = 10
+	}
+
+	feed := services.Feed(database.Instance, auth.UsuarioLogado(r).ID, pagina, tamanho)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(feed)
+}
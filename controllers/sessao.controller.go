@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"blogpessoal/auth"
+	"blogpessoal/database"
+	"blogpessoal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// getSessoes godoc
+// @Summary Listar Sessoes
+// @Description Lista os dispositivos em que o Usuario logado está conectado, com o IP, o User-Agent e a última atividade de cada Sessao. A Sessao da requisição vem marcada como atual.
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} model.Sessao
+// @Router /usuarios/sessoes [get]
+// @Security Bearer
+func GetSessoes(w http.ResponseWriter, r *http.Request) {
+
+	sessoes := services.ListarSessoes(database.Instance, auth.UsuarioLogado(r).ID, auth.SessaoAtual(r).ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(sessoes)
+}
+
+// revokeSessao godoc
+// @Summary Encerrar Sessao
+// @Description Encerra uma Sessao do Usuario logado; o token dela deixa de valer imediatamente
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id da Sessao"
+// @Success 204 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /usuarios/sessoes/{id} [delete]
+// @Security Bearer
+func RevokeSessao(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	sessaoId, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+
+	err := services.EncerrarSessao(database.Instance, auth.UsuarioLogado(r).ID, uint(sessaoId))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Sessão não encontrada!")
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível encerrar a Sessão!")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeOutrasSessoes godoc
+// @Summary Encerrar as outras Sessoes
+// @Description Encerra todas as Sessoes do Usuario logado, menos a da requisição
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} map[string]int64
+// @Router /usuarios/sessoes [delete]
+// @Security Bearer
+func RevokeOutrasSessoes(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	encerradas, err := services.EncerrarOutrasSessoes(database.Instance, auth.UsuarioLogado(r).ID, auth.SessaoAtual(r).ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível encerrar as Sessões!")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]int64{"encerradas": encerradas})
+}
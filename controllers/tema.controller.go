@@ -1,15 +1,19 @@
 package controllers
 
 import (
+	"blogpessoal/auth"
 	"blogpessoal/database"
 	"blogpessoal/model"
+	"blogpessoal/services"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 )
 
 // getAll godoc
@@ -18,17 +22,17 @@ import (
 // @Tags temas
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} model.Tema
+// @Success 200 {array} model.TemaResposta
 // @Router /temas [get]
 // @Security Bearer
-func GetTemas(w http.ResponseWriter, _ *http.Request) {
+func GetTemas(w http.ResponseWriter, r *http.Request) {
 
 	var temas []model.Tema
 
-	database.Instance.Preload("Postagens").Find(&temas)
+	database.Instance.Preload("Postagens", services.PostagensVisiveis(auth.UsuarioLogado(r))).Order("ordem, descricao").Find(&temas)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(temas)
+	json.NewEncoder(w).Encode(model.TemasResposta(temas))
 }
 
 // getById godoc
@@ -38,7 +42,7 @@ func GetTemas(w http.ResponseWriter, _ *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Id do Tema"
-// @Success 200 {array} model.Tema
+// @Success 200 {object} model.TemaResposta
 // @Success 400 {object} errorResponse
 // @Success 404 {object} errorResponse
 // @Success 405 {object} errorResponse
@@ -56,10 +60,10 @@ func GetTemaById(w http.ResponseWriter, r *http.Request) {
 
 	var tema model.Tema
 
-	database.Instance.Preload("Postagens").First(&tema, temaId)
+	database.Instance.Preload("Postagens", services.PostagensVisiveis(auth.UsuarioLogado(r))).First(&tema, temaId)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(tema)
+	json.NewEncoder(w).Encode(tema.Resposta())
 }
 
 // getByDescricao godoc
@@ -69,7 +73,7 @@ func GetTemaById(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param descricao path string true "Descrição do Tema"
-// @Success 200 {array} model.Tema
+// @Success 200 {array} model.TemaResposta
 // @Success 400 {object} errorResponse
 // @Success 405 {object} errorResponse
 // @Router /temas/descricao/{descricao} [get]
@@ -80,10 +84,133 @@ func GetTemaByDescricao(w http.ResponseWriter, r *http.Request) {
 
 	var temas []model.Tema
 
-	database.Instance.Preload("Postagens").Where("descricao LIKE ?", "%"+temaDescricao+"%").Find(&temas)
+	database.Instance.Preload("Postagens", services.PostagensVisiveis(auth.UsuarioLogado(r))).Where("descricao_normalizada LIKE ?", "%"+model.NormalizarTexto(temaDescricao)+"%").Order("ordem, descricao").Find(&temas)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(temas)
+	json.NewEncoder(w).Encode(model.TemasResposta(temas))
+}
+
+// getArvore godoc
+// @Summary Listar a árvore de Temas
+// @Description Lista todos os Temas organizados por hierarquia, com a quantidade de Postagens de cada um
+// @Tags temas
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} services.NoTema
+// @Router /temas/arvore [get]
+// @Security Bearer
+func GetTemaArvore(w http.ResponseWriter, _ *http.Request) {
+
+	arvore := services.ArvoreTemas(database.Instance)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(arvore)
+}
+
+// getEstatisticas godoc
+// @Summary Estatísticas dos Temas
+// @Description Lista, por Tema, o total de Postagens, a última Postagem, os autores e as Postagens por mês
+// @Tags temas
+// @Accept  json
+// @Produce  json
+// @Param autores query int false "Quantidade de principais autores por Tema (padrão 3)"
+// @Param meses query int false "Meses da série de Postagens por mês (padrão 12)"
+// @Success 200 {array} services.EstatisticaTema
+// @Router /temas/estatisticas [get]
+// @Security Bearer
+func GetTemaEstatisticas(w http.ResponseWriter, r *http.Request) {
+
+	autores, err := strconv.Atoi(r.URL.Query().Get("autores"))
+	if err != nil || autores <= 0 {
+		autores = 3
+	}
+
+	meses, err := strconv.Atoi(r.URL.Query().Get("meses"))
+	if err != nil || meses <= 0 || meses > 120 {
+		meses = 12
+	}
+
+	estatisticas := services.EstatisticasTemas(database.Instance, autores, meses)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(estatisticas)
+}
+
+// getPostagensByTema godoc
+// @Summary Listar Postagens do Tema
+// @Description Lista as Postagens de um Tema e, opcionalmente, dos seus subtemas
+// @Tags temas
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Tema"
+// @Param incluir_subtemas query bool false "Inclui as Postagens dos subtemas"
+// @Success 200 {array} model.PostagemResposta
+// @Success 404 {object} errorResponse
+// @Router /temas/{id}/postagens [get]
+// @Security Bearer
+func GetPostagensByTema(w http.ResponseWriter, r *http.Request) {
+
+	temaId := mux.Vars(r)["id"]
+
+	if !checkIfTemaExists(temaId) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Tema Não Encontrado!")
+		return
+	}
+
+	id, _ := strconv.ParseUint(temaId, 10, 32)
+	temas := []uint{uint(id)}
+
+	if r.URL.Query().Get("incluir_subtemas") == "true" {
+		temas = services.DescendentesTema(database.Instance, uint(id))
+	}
+
+	var postagens []model.Postagem
+
+	database.Instance.Joins("Tema").Joins("Usuario").Scopes(services.PostagensVisiveis(auth.UsuarioLogado(r))).Where("tema_id IN ?", temas).Order("data DESC").Find(&postagens)
+	services.PreencherBreadcrumbs(database.Instance, postagens)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(model.PostagensResposta(postagens))
+}
+
+// getBySlug godoc
+// @Summary Listar Tema por slug
+// @Description Lista um Tema pelo slug, redirecionando os slugs de Temas que foram mesclados
+// @Tags temas
+// @Accept  json
+// @Produce  json
+// @Param slug path string true "Slug do Tema"
+// @Success 200 {object} model.TemaResposta
+// @Success 301 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /temas/slug/{slug} [get]
+// @Security Bearer
+func GetTemaBySlug(w http.ResponseWriter, r *http.Request) {
+
+	temaSlug := mux.Vars(r)["slug"]
+
+	var tema model.Tema
+
+	database.Instance.Where("slug = ?", temaSlug).Find(&tema)
+
+	if tema.ID == 0 {
+		var redirecionamento model.TemaRedirecionamento
+		database.Instance.Joins("Tema").Where("slug_antigo = ?", temaSlug).Find(&redirecionamento)
+
+		if redirecionamento.ID == 0 || redirecionamento.Tema.ID == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode("Tema Não Encontrado!")
+			return
+		}
+
+		http.Redirect(w, r, "/temas/slug/"+redirecionamento.Tema.Slug, http.StatusMovedPermanently)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tema.Resposta())
 }
 
 // postTema godoc
@@ -92,18 +219,18 @@ func GetTemaByDescricao(w http.ResponseWriter, r *http.Request) {
 // @Tags temas
 // @Accept  json
 // @Produce  json
-// @Param tema body model.Tema true "Criar Tema"
-// @Success 201 {object} model.Tema
+// @Param tema body model.TemaRequisicao true "Criar Tema"
+// @Success 201 {object} model.TemaResposta
 // @Router /temas [post]
 // @Security Bearer
 func CreateTema(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	var tema model.Tema
-	json.NewDecoder(r.Body).Decode(&tema)
+	var requisicao model.TemaRequisicao
+	json.NewDecoder(r.Body).Decode(&requisicao)
 
 	validate := validator.New()
 
-	err := validate.Struct(tema)
+	err := validate.Struct(requisicao)
 	if err != nil {
 		validationErrors := err.(validator.ValidationErrors)
 		w.Header().Add("Content-Type", "application/json")
@@ -115,9 +242,28 @@ func CreateTema(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	database.Instance.Create(&tema)
+	tema := requisicao.Modelo()
+
+	if !checkIfTemaParentValido(tema) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Tema Pai Inválido!")
+		return
+	}
+
+	if checkIfTemaDescricaoExists(tema) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode("Tema já Cadastrado!")
+		return
+	}
+
+	if err := database.Instance.Create(&tema).Error; err != nil {
+		writeTemaSaveError(w, err)
+		return
+	}
+	services.DispararWebhookTema(database.Instance, services.EventoTemaCriado, tema)
+
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(tema)
+	json.NewEncoder(w).Encode(tema.Resposta())
 }
 
 // putTema godoc
@@ -127,8 +273,8 @@ func CreateTema(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Id do tema"
-// @Param Tema body model.Tema true "Atualizar Tema"
-// @Success 200 {object} model.Tema
+// @Param Tema body model.TemaRequisicao true "Atualizar Tema"
+// @Success 200 {object} model.TemaResposta
 // @Success 400 {object} errorResponse
 // @Success 404 {object} errorResponse
 // @Success 405 {object} errorResponse
@@ -136,12 +282,12 @@ func CreateTema(w http.ResponseWriter, r *http.Request) {
 // @Security Bearer
 func UpdateTema(w http.ResponseWriter, r *http.Request) {
 
-	var tema model.Tema
-	json.NewDecoder(r.Body).Decode(&tema)
+	var requisicao model.TemaRequisicao
+	json.NewDecoder(r.Body).Decode(&requisicao)
 	
 	validate := validator.New()
 
-	err := validate.Struct(tema)
+	err := validate.Struct(requisicao)
 	if err != nil {
 		validationErrors := err.(validator.ValidationErrors)
 		w.Header().Add("Content-Type", "application/json")
@@ -153,6 +299,8 @@ func UpdateTema(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tema := requisicao.Modelo()
+
 	var id = strconv.FormatUint(uint64(tema.ID), 10)
 
 	if !checkIfTemaExists(id) {
@@ -161,23 +309,115 @@ func UpdateTema(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	database.Instance.Save(&tema)
+	if !checkIfTemaParentValido(tema) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Tema Pai Inválido!")
+		return
+	}
+
+	if checkIfTemaDescricaoExists(tema) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode("Tema já Cadastrado!")
+		return
+	}
+
+	if err := database.Instance.Save(&tema).Error; err != nil {
+		writeTemaSaveError(w, err)
+		return
+	}
+	services.DispararWebhookTema(database.Instance, services.EventoTemaAtualizado, tema)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tema.Resposta())
+}
+
+// mesclarTema godoc
+// @Summary Mesclar Temas
+// @Description Move as Postagens e os subtemas do Tema para o Tema de destino e apaga o Tema, redirecionando o slug antigo
+// @Tags temas
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Tema de origem"
+// @Param mesclagem body controllers.MesclagemTema true "Tema de destino"
+// @Success 200 {object} model.TemaResposta
+// @Success 400 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /temas/{id}/mesclar [post]
+// @Security Bearer
+func MergeTema(w http.ResponseWriter, r *http.Request) {
+
+	temaId := mux.Vars(r)["id"]
+
+	var mesclagem MesclagemTema
+	json.NewDecoder(r.Body).Decode(&mesclagem)
+
+	validate := validator.New()
+
+	err := validate.Struct(mesclagem)
+	if err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		w.WriteHeader(http.StatusBadRequest)
+		responseBody := map[string]string{"error": validationErrors.Error()}
+		if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+			log.Fatalf("Erro: %s", err)
+		}
+		return
+	}
+
+	if !checkIfTemaExists(temaId) || !checkIfTemaExists(strconv.FormatUint(uint64(mesclagem.DestinoID), 10)) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Tema Não Encontrado!")
+		return
+	}
+
+	origemId, _ := strconv.ParseUint(temaId, 10, 32)
+
+	err = services.MesclarTemas(database.Instance, uint(origemId), mesclagem.DestinoID)
+	if errors.Is(err, services.ErrMesclagemMesmoTema) || errors.Is(err, services.ErrMesclagemDescendente) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível mesclar os Temas!")
+		return
+	}
+
+	services.DispararWebhook(database.Instance, services.EventoTemaMesclado, map[string]uint{
+		"origem_id":  uint(origemId),
+		"destino_id": mesclagem.DestinoID,
+	})
+
+	var destino model.Tema
+
+	database.Instance.First(&destino, mesclagem.DestinoID)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(tema)
+	json.NewEncoder(w).Encode(destino.Resposta())
+}
+
+// MesclagemTema é o corpo de POST /temas/{id}/mesclar
+type MesclagemTema struct {
+	DestinoID uint `json:"destino_id" validate:"required" example:"2"`
 }
 
 // deleteTema godoc
 // @Summary Deletar Tema
-// @Description Apaga uma Tema
+// @Description Apaga uma Tema. Se ele tiver Postagens, é preciso informar a estratégia: reatribuir (com destino_id), sem_categoria ou cascata (com confirmar=true)
 // @Tags temas
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Id do Tema"
+// @Param estrategia query string false "reatribuir, sem_categoria ou cascata"
+// @Param destino_id query int false "Tema que recebe as Postagens na estratégia reatribuir"
+// @Param confirmar query bool false "Confirma a exclusão das Postagens na estratégia cascata"
 // @Success 204 {object} errorResponse
 // @Success 400 {object} errorResponse
 // @Success 404 {object} errorResponse
 // @Success 405 {object} errorResponse
+// @Success 409 {object} errorResponse
 // @Router /temas/{id} [delete]
 // @Security Bearer
 func DeleteTema(w http.ResponseWriter, r *http.Request) {
@@ -190,13 +430,76 @@ func DeleteTema(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	id, _ := strconv.ParseUint(temaId, 10, 32)
+	destinoId, _ := strconv.ParseUint(r.URL.Query().Get("destino_id"), 10, 32)
+
 	var tema model.Tema
+	database.Instance.First(&tema, id)
+
+	err := services.ExcluirTema(database.Instance, uint(id), services.ExclusaoTema{
+		Estrategia: r.URL.Query().Get("estrategia"),
+		DestinoID:  uint(destinoId),
+		Confirmado: r.URL.Query().Get("confirmar") == "true",
+	})
+
+	switch {
+	case errors.Is(err, services.ErrTemaComPostagens):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	case errors.Is(err, services.ErrEstrategiaInvalida), errors.Is(err, services.ErrDestinoInvalido), errors.Is(err, services.ErrConfirmacaoObrigatoria):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	case err != nil:
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível apagar o Tema!")
+		return
+	}
+
+	services.DispararWebhookTema(database.Instance, services.EventoTemaRemovido, tema)
 
-	database.Instance.Delete(&tema, temaId)
 	w.WriteHeader(http.StatusNoContent)
 	json.NewEncoder(w).Encode("Tema Deletado!")
 }
 
+// checkIfTemaDescricaoExists procura outro Tema com a mesma descrição, ignorando acentos, caixa e espaços.
+// Os Temas na lixeira também contam, pois continuam no índice único.
+func checkIfTemaDescricaoExists(tema model.Tema) bool {
+
+	var total int64
+	database.Instance.Unscoped().Model(&model.Tema{}).Where("descricao_normalizada = ? AND id <> ?", model.NormalizarTexto(tema.Descricao), tema.ID).Count(&total)
+
+	return total > 0
+}
+
+// writeTemaSaveError responde 409 quando o índice único recusa o Tema (cadastros simultâneos)
+func writeTemaSaveError(w http.ResponseWriter, err error) {
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode("Tema já Cadastrado!")
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode("Não foi possível salvar o Tema!")
+}
+
+// checkIfTemaParentValido verifica se o Tema pai existe e não é o próprio Tema ou um descendente dele
+func checkIfTemaParentValido(tema model.Tema) bool {
+
+	if tema.ParentID == nil {
+		return true
+	}
+
+	if !checkIfTemaExists(strconv.FormatUint(uint64(*tema.ParentID), 10)) {
+		return false
+	}
+
+	return !services.CriaCiclo(database.Instance, tema.ID, tema.ParentID)
+}
+
 func checkIfTemaExists(temaId string) bool {
 
 	var tema model.Tema
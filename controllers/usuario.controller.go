@@ -1,45 +1,59 @@
 ﻿package controllers
 
 import (
+	"blogpessoal/auth"
 	"blogpessoal/database"
 	"blogpessoal/model"
+	"blogpessoal/services"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
-	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // getAll godoc
 // @Summary Listar Usuarios
-// @Description Lista todos os Usuarios
+// @Description Lista os Usuarios. Administradores recebem todos, com a visão completa (model.UsuarioAdmin); os demais Usuarios não veem as contas banidas ou pendentes e recebem o perfil público de cada um, exceto o próprio (model.UsuarioProprio).
 // @Tags usuarios
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} model.Usuario
+// @Success 200 {array} model.UsuarioPublico
 // @Router /usuarios [get]
 // @Security Bearer
-func GetUsuarios(w http.ResponseWriter, _ *http.Request) {
+func GetUsuarios(w http.ResponseWriter, r *http.Request) {
 
 	var usuarios []model.Usuario
 
-	database.Instance.Preload("Postagens").Find(&usuarios)
+	logado := auth.UsuarioLogado(r)
+	consulta := database.Instance.Preload("Postagens", services.PostagensVisiveis(logado))
+	if logado.Perfil != model.PerfilAdmin {
+		consulta = consulta.Scopes(services.UsuariosVisiveis)
+	}
+	consulta.Find(&usuarios)
+
+	resposta := make([]interface{}, len(usuarios))
+	for i, usuario := range usuarios {
+		resposta[i] = usuarioResposta(logado, usuario)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(usuarios)
+	json.NewEncoder(w).Encode(resposta)
 }
 
 // getById godoc
 // @Summary Listar Usuario por id
-// @Description Lista um Usuario por id
+// @Description Lista um Usuario por id. Administradores recebem a visão completa (model.UsuarioAdmin), o próprio Usuario recebe o seu perfil (model.UsuarioProprio) e os demais, o perfil público.
 // @Tags usuarios
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Id do Usuario"
-// @Success 200 {array} model.Usuario
+// @Success 200 {object} model.UsuarioPublico
 // @Success 400 {object} errorResponse
 // @Success 404 {object} errorResponse
 // @Success 405 {object} errorResponse
@@ -57,29 +71,51 @@ func GetUsuarioById(w http.ResponseWriter, r *http.Request) {
 
 	var usuario model.Usuario
 
-	database.Instance.Preload("Postagens").First(&usuario, usuarioId)
+	// Os rascunhos só aparecem para o autor e os administradores
+	logado := auth.UsuarioLogado(r)
+	database.Instance.Preload("Postagens", services.PostagensVisiveis(logado)).First(&usuario, usuarioId)
+
+	// Contas banidas ou pendentes só são vistas pelos administradores
+	if !usuario.Visivel() && logado.Perfil != model.PerfilAdmin && logado.ID != usuario.ID {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Usuario Não Encontrada!")
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(usuario)
+	json.NewEncoder(w).Encode(usuarioResposta(logado, usuario))
 }
 
 // postUsuario godoc
 // @Summary Criar Usuario
-// @Description Cria um novo Usuario
+// @Description Cria um novo Usuario conforme o modo de cadastro do blog (GET /usuarios/cadastro). No modo por convite o código do convite é obrigatório; no modo com aprovação, o cadastro sem convite fica pendente até um administrador aprová-lo e a resposta é 202.
 // @Tags usuarios
 // @Accept  json
 // @Produce  json
-// @Param usuario body model.Usuario true "Criar Usuario"
-// @Success 201 {object} model.Usuario
-// @Router /usuarios [post]
+// @Param usuario body model.UsuarioCadastro true "Criar Usuario"
+// @Success 201 {object} model.UsuarioProprio
+// @Success 202 {object} model.UsuarioProprio
+// @Success 400 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Success 409 {object} errorResponse
+// @Router /usuarios/cadastrar [post]
 func CreateUsuario(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	var usuario model.Usuario
-	json.NewDecoder(r.Body).Decode(&usuario)
+
+	if services.ModoCadastro == services.CadastroFechado {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(services.ErrCadastroFechado.Error())
+		return
+	}
+
+	var cadastro model.UsuarioCadastro
+	json.NewDecoder(r.Body).Decode(&cadastro)
+	cadastro.Usuario = model.NormalizarEmail(cadastro.Usuario)
 
 	validate := validator.New()
 
-	err := validate.Struct(usuario)
+	err := validate.Struct(cadastro)
 	if err != nil {
 		validationErrors := err.(validator.ValidationErrors)
 		w.Header().Add("Content-Type", "application/json")
@@ -91,42 +127,64 @@ func CreateUsuario(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if checkIfUsuarioEmailExists(usuario.Usuario) {
-		w.WriteHeader(http.StatusBadRequest)
+	if checkIfUsuarioEmailExists(cadastro.Usuario) {
+		w.WriteHeader(http.StatusConflict)
 		json.NewEncoder(w).Encode("Usuario já Cadastrado!")
 		return
 	}
 
+	usuario := cadastro.Modelo()
+
+	if err := services.ValidarSenha(usuario.Senha, usuario); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+
 	hash, _ := HashPassword(usuario.Senha)
 	usuario.Senha = hash
 
-	database.Instance.Create(&usuario)
+	if err := services.CadastrarUsuario(database.Instance, &usuario, cadastro.Convite); err != nil {
+		writeUsuarioSaveError(w, err)
+		return
+	}
+
+	if usuario.Situacao == model.SituacaoPendente {
+		services.NotificarCadastroPendente(database.Instance, usuario)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(usuario.Proprio())
+		return
+	}
+	services.DispararWebhookUsuario(database.Instance, services.EventoUsuarioCriado, usuario)
+
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(usuario)
+	json.NewEncoder(w).Encode(usuario.Proprio())
 }
 
 // putUsuario godoc
 // @Summary Atualizar Usuario
-// @Description Edita um Usuario
+// @Description Edita os dados de um Usuario. Apenas administradores; a senha é trocada pelo próprio Usuario em /usuarios/eu/senha.
 // @Tags usuarios
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Id do usuario"
-// @Param Usuario body model.Usuario true "Atualizar Usuario"
-// @Success 200 {object} model.Usuario
+// @Param Usuario body model.UsuarioAtualizacao true "Atualizar Usuario"
+// @Success 200 {object} model.UsuarioProprio
 // @Success 400 {object} errorResponse
 // @Success 404 {object} errorResponse
+// @Success 403 {object} errorResponse
 // @Success 405 {object} errorResponse
 // @Router /usuarios/atualizar [put]
 // @Security Bearer
 func UpdateUsuario(w http.ResponseWriter, r *http.Request) {
 
-	var usuario model.Usuario
-	json.NewDecoder(r.Body).Decode(&usuario)
+	var atualizacao model.UsuarioAtualizacao
+	json.NewDecoder(r.Body).Decode(&atualizacao)
+	atualizacao.Usuario = model.NormalizarEmail(atualizacao.Usuario)
 	
 	validate := validator.New()
 
-	err := validate.Struct(usuario)
+	err := validate.Struct(atualizacao)
 	if err != nil {
 		validationErrors := err.(validator.ValidationErrors)
 		w.Header().Add("Content-Type", "application/json")
@@ -138,7 +196,7 @@ func UpdateUsuario(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var id = strconv.FormatUint(uint64(usuario.ID), 10)
+	var id = strconv.FormatUint(uint64(atualizacao.ID), 10)
 	
 	if !checkIfUsuarioExists(id){
 		w.WriteHeader(http.StatusNotFound)
@@ -147,21 +205,40 @@ func UpdateUsuario(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var buscarUsuario model.Usuario
-	database.Instance.Where("usuario = ?", usuario.Usuario).Find(&buscarUsuario) 
+	database.Instance.Where("usuario = ?", atualizacao.Usuario).Find(&buscarUsuario) 
 	
-	if checkIfUsuarioEmailExists(usuario.Usuario) && usuario.ID != buscarUsuario.ID{
-		w.WriteHeader(http.StatusBadRequest)
+	if checkIfUsuarioEmailExists(atualizacao.Usuario) && atualizacao.ID != buscarUsuario.ID{
+		w.WriteHeader(http.StatusConflict)
 		json.NewEncoder(w).Encode("Usuário já Cadastrado!")
 		return
 	}
 	
-	hash, _ := HashPassword(usuario.Senha)
-	usuario.Senha = hash
+	// O perfil só pode ser alterado diretamente no banco de dados
+	var usuario model.Usuario
+	database.Instance.First(&usuario, atualizacao.ID)
+	atualizacao.Aplicar(&usuario)
 
-	database.Instance.Save(&usuario)
 	w.Header().Set("Content-Type", "application/json")
+	if err := database.Instance.Save(&usuario).Error; err != nil {
+		writeUsuarioSaveError(w, err)
+		return
+	}
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(usuario)
+	json.NewEncoder(w).Encode(usuario.Proprio())
+}
+
+// usuarioResposta escolhe a visão do Usuario conforme quem a solicita: o administrador vê todos
+// os dados, o próprio Usuario vê o seu perfil e os demais veem apenas o perfil público
+func usuarioResposta(logado model.Usuario, usuario model.Usuario) interface{} {
+
+	switch {
+	case logado.Perfil == model.PerfilAdmin:
+		return usuario.Admin()
+	case logado.ID == usuario.ID:
+		return usuario.Proprio()
+	default:
+		return usuario.Publico()
+	}
 }
 
 func checkIfUsuarioExists(usuarioId string) bool {
@@ -173,21 +250,42 @@ func checkIfUsuarioExists(usuarioId string) bool {
 
 }
 
+// writeUsuarioSaveError responde 409 quando o índice único recusa o e-mail (cadastros simultâneos)
+// e 403 quando o modo de cadastro ou o Convite não permitem a conta
+func writeUsuarioSaveError(w http.ResponseWriter, err error) {
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode("Usuario já Cadastrado!")
+		return
+	}
+
+	if errors.Is(err, services.ErrCadastroFechado) || errors.Is(err, services.ErrConviteObrigatorio) || errors.Is(err, services.ErrConviteInvalido) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode("Não foi possível salvar o Usuario!")
+}
+
+// checkIfUsuarioEmailExists procura o e-mail normalizado, incluindo os Usuarios na lixeira,
+// que continuam ocupando o índice único
 func checkIfUsuarioEmailExists(usuarioEmail string) bool {
 
 	var usuario model.Usuario
-	database.Instance.Where("usuario = ?", usuarioEmail).Find(&usuario)
+	database.Instance.Unscoped().Where("usuario = ?", model.NormalizarEmail(usuarioEmail)).Find(&usuario)
 
 	return usuario.Usuario != ""
 
 }
 
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
+	return services.GerarHashSenha(password)
 }
 
 func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	confere, _ := services.ConferirSenha(password, hash)
+	return confere
 }
@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"blogpessoal/database"
+	"blogpessoal/model"
+	"blogpessoal/services"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// getWebhooks godoc
+// @Summary Listar Webhooks
+// @Description Lista os Webhooks cadastrados
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} model.WebhookResposta
+// @Success 403 {object} errorResponse
+// @Router /admin/webhooks [get]
+// @Security Bearer
+func GetWebhooks(w http.ResponseWriter, _ *http.Request) {
+
+	var webhooks []model.Webhook
+
+	database.Instance.Order("id").Find(&webhooks)
+
+	resposta := make([]model.WebhookResposta, len(webhooks))
+	for i, webhook := range webhooks {
+		resposta[i] = webhook.Resposta()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resposta)
+}
+
+// getWebhookById godoc
+// @Summary Buscar Webhook por id
+// @Description Busca um Webhook pelo id
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Webhook"
+// @Success 200 {object} model.WebhookResposta
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /admin/webhooks/{id} [get]
+// @Security Bearer
+func GetWebhookById(w http.ResponseWriter, r *http.Request) {
+
+	webhookId := mux.Vars(r)["id"]
+
+	if !checkIfWebhookExists(webhookId) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Webhook Não Encontrado!")
+		return
+	}
+
+	var webhook model.Webhook
+
+	database.Instance.First(&webhook, webhookId)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(webhook.Resposta())
+}
+
+// postWebhook godoc
+// @Summary Criar Webhook
+// @Description Cadastra um Webhook. Os eventos podem ser nomes exatos, grupos como "tema.*" ou "*". Se o segredo não for informado, um é gerado. O segredo só é mostrado nesta resposta.
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param webhook body model.WebhookRequisicao true "Criar Webhook"
+// @Success 201 {object} model.WebhookCriado
+// @Success 400 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Success 500 {object} errorResponse
+// @Router /admin/webhooks [post]
+// @Security Bearer
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	var requisicao model.WebhookRequisicao
+	json.NewDecoder(r.Body).Decode(&requisicao)
+
+	if !validarWebhook(w, requisicao) {
+		return
+	}
+
+	webhook := requisicao.Modelo()
+	webhook.ID = 0
+	webhook.Ativo = true
+	if webhook.Segredo == "" {
+		webhook.Segredo = services.GerarSegredoWebhook()
+	}
+
+	if err := database.Instance.Create(&webhook).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível criar o Webhook!")
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(webhook.Criado())
+}
+
+// putWebhook godoc
+// @Summary Atualizar Webhook
+// @Description Edita um Webhook. Reativar um Webhook desativado zera a contagem de falhas. Sem segredo, o atual é mantido.
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param webhook body model.WebhookRequisicao true "Atualizar Webhook"
+// @Success 200 {object} model.WebhookResposta
+// @Success 400 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Success 500 {object} errorResponse
+// @Router /admin/webhooks [put]
+// @Security Bearer
+func UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	var requisicao model.WebhookRequisicao
+	json.NewDecoder(r.Body).Decode(&requisicao)
+
+	if !validarWebhook(w, requisicao) {
+		return
+	}
+
+	webhook := requisicao.Modelo()
+	var atual model.Webhook
+	database.Instance.Find(&atual, webhook.ID)
+
+	if atual.ID == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Webhook Não Encontrado!")
+		return
+	}
+
+	webhook.CreatedAt = atual.CreatedAt
+	webhook.FalhasConsecutivas = atual.FalhasConsecutivas
+	webhook.DesativadoEm = atual.DesativadoEm
+	if webhook.Segredo == "" {
+		webhook.Segredo = atual.Segredo
+	}
+	if webhook.Ativo && !atual.Ativo {
+		webhook.FalhasConsecutivas = 0
+		webhook.DesativadoEm = nil
+	}
+
+	if err := database.Instance.Save(&webhook).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível atualizar o Webhook!")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(webhook.Resposta())
+}
+
+// deleteWebhook godoc
+// @Summary Deletar Webhook
+// @Description Apaga um Webhook e o seu registro de entregas
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Webhook"
+// @Success 204 {object} errorResponse
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /admin/webhooks/{id} [delete]
+// @Security Bearer
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	webhookId := mux.Vars(r)["id"]
+
+	if !checkIfWebhookExists(webhookId) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Webhook Não Encontrado!")
+		return
+	}
+
+	var webhook model.Webhook
+
+	database.Instance.Delete(&webhook, webhookId)
+	w.WriteHeader(http.StatusNoContent)
+	json.NewEncoder(w).Encode("Webhook Deletado!")
+}
+
+// getEntregasWebhook godoc
+// @Summary Listar Entregas do Webhook
+// @Description Lista as entregas mais recentes de um Webhook, com as tentativas e a resposta recebida
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id do Webhook"
+// @Param status query string false "pendente, sucesso ou falha"
+// @Param limite query int false "Quantidade de entregas (padrão 50, máximo 500)"
+// @Success 200 {array} model.EntregaWebhook
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /admin/webhooks/{id}/entregas [get]
+// @Security Bearer
+func GetEntregasWebhook(w http.ResponseWriter, r *http.Request) {
+
+	webhookId := mux.Vars(r)["id"]
+
+	if !checkIfWebhookExists(webhookId) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Webhook Não Encontrado!")
+		return
+	}
+
+	limite, err := strconv.Atoi(r.URL.Query().Get("limite"))
+	if err != nil || limite <= 0 || limite > 500 {
+		limite = 50
+	}
+
+	consulta := database.Instance.Where("webhook_id = ?", webhookId)
+	if status := r.URL.Query().Get("status"); status != "" {
+		consulta = consulta.Where("status = ?", status)
+	}
+
+	entregas := []model.EntregaWebhook{}
+
+	consulta.Order("id DESC").Limit(limite).Find(&entregas)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entregas)
+}
+
+// replayEntregaWebhook godoc
+// @Summary Reenviar Entrega
+// @Description Envia de novo o payload de uma entrega, registrando uma nova entrega
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Id da Entrega"
+// @Success 202 {object} model.EntregaWebhook
+// @Success 403 {object} errorResponse
+// @Success 404 {object} errorResponse
+// @Router /admin/webhooks/entregas/{id}/reenviar [post]
+// @Security Bearer
+func ReplayEntregaWebhook(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	entregaId, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+
+	entrega, err := services.ReenviarEntrega(database.Instance, uint(entregaId))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode("Entrega Não Encontrada!")
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode("Não foi possível reenviar a Entrega!")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(entrega)
+}
+
+// validarWebhook responde 400 se o Webhook for inválido ou assinar um evento desconhecido
+func validarWebhook(w http.ResponseWriter, webhook model.WebhookRequisicao) bool {
+
+	validate := validator.New()
+
+	err := validate.Struct(webhook)
+	if err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		w.WriteHeader(http.StatusBadRequest)
+		responseBody := map[string]string{"error": validationErrors.Error()}
+		if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+			log.Fatalf("Erro: %s", err)
+		}
+		return false
+	}
+
+	for _, evento := range webhook.Eventos {
+		if !services.EventoValido(evento) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode("Evento Inválido: " + evento)
+			return false
+		}
+	}
+
+	return true
+}
+
+func checkIfWebhookExists(webhookId string) bool {
+
+	var webhook model.Webhook
+
+	database.Instance.First(&webhook, webhookId)
+
+	return webhook.ID != 0
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"blogpessoal/model"
+	"fmt"
 	"log"
 
 	"gorm.io/driver/mysql"
@@ -12,7 +13,7 @@ var Instance *gorm.DB
 var err error
 
 func Connect(connectionString string) {
-	Instance, err = gorm.Open(mysql.Open(connectionString), &gorm.Config{})
+	Instance, err = gorm.Open(mysql.Open(connectionString), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatal(err)
 		panic("Não foi possível conectar ao banco de dados!")
@@ -22,8 +23,125 @@ func Connect(connectionString string) {
 }
 
 func Migrate() {
+	preencherSlugs(&model.Postagem{}, model.Postagem{}.TableName(), "titulo")
+	preencherSlugs(&model.Tema{}, model.Tema{}.TableName(), "descricao")
+	preencherSlugs(&model.Usuario{}, model.Usuario{}.TableName(), "nome")
+	preencherDescricoesNormalizadas()
+	normalizarEmails()
+	preencherPublicacoes()
+
 	Instance.AutoMigrate(&model.Postagem{})
 	Instance.AutoMigrate(&model.Tema{})
 	Instance.AutoMigrate(&model.Usuario{})
+	Instance.AutoMigrate(&model.TemaRedirecionamento{})
+	Instance.AutoMigrate(&model.SeguidorUsuario{})
+	Instance.AutoMigrate(&model.SeguidorTema{})
+	Instance.AutoMigrate(&model.Notificacao{})
+	Instance.AutoMigrate(&model.AssinaturaResumo{})
+	Instance.AutoMigrate(&model.Webhook{})
+	Instance.AutoMigrate(&model.EntregaWebhook{})
+	Instance.AutoMigrate(&model.Sessao{})
+	Instance.AutoMigrate(&model.Convite{})
+	atualizarExclusaoPostagensTema()
 	log.Println("Criação das Tabelas Finalizada...")
 }
+
+// atualizarExclusaoPostagensTema troca o antigo ON DELETE CASCADE das Postagens por RESTRICT,
+// para que apagar um Tema nunca apague Postagens sem uma estratégia explícita
+func atualizarExclusaoPostagensTema() {
+	var regra string
+	Instance.Raw("SELECT DELETE_RULE FROM information_schema.REFERENTIAL_CONSTRAINTS WHERE CONSTRAINT_SCHEMA = DATABASE() AND CONSTRAINT_NAME = ?", "fk_tb_temas_postagens").Scan(&regra)
+
+	if regra == "CASCADE" {
+		Instance.Migrator().DropConstraint(&model.Tema{}, "Postagens")
+		Instance.Migrator().CreateConstraint(&model.Tema{}, "Postagens")
+	}
+}
+
+// preencherPublicacoes cria a coluna publicada_em e, para as Postagens já publicadas, usa a data
+// da última alteração, para que elas não entrem como novidade no próximo resumo por e-mail
+func preencherPublicacoes() {
+	if !Instance.Migrator().HasTable(&model.Postagem{}) || Instance.Migrator().HasColumn(&model.Postagem{}, "PublicadaEm") {
+		return
+	}
+
+	Instance.Migrator().AddColumn(&model.Postagem{}, "PublicadaEm")
+	Instance.Exec("UPDATE "+model.Postagem{}.TableName()+" SET publicada_em = data WHERE status = ?", model.StatusPublicado)
+}
+
+// preencherSlugs cria a coluna slug nas tabelas já existentes e gera o slug dos
+// registros antigos antes que o AutoMigrate crie o índice único
+func preencherSlugs(modelo interface{}, tabela string, coluna string) {
+	if !Instance.Migrator().HasTable(modelo) {
+		return
+	}
+	if !Instance.Migrator().HasColumn(modelo, "Slug") {
+		Instance.Migrator().AddColumn(modelo, "Slug")
+	}
+
+	var registros []struct {
+		ID    uint
+		Texto string
+	}
+	Instance.Table(tabela).Select("id, " + coluna + " AS texto").Where("slug = '' OR slug IS NULL").Scan(&registros)
+
+	for _, registro := range registros {
+		slug := model.GerarSlugUnico(Instance, tabela, "", registro.Texto, registro.ID)
+		Instance.Table(tabela).Where("id = ?", registro.ID).UpdateColumn("slug", slug)
+	}
+}
+
+// normalizarEmails prepara o índice único de e-mails nas tabelas já existentes, gravando cada
+// e-mail normalizado. Quando dois Usuarios antigos só diferem na caixa, o mais antigo fica com o
+// e-mail e os demais recebem o id como sufixo, sem conseguir entrar até um administrador corrigi-los.
+func normalizarEmails() {
+	usuario := &model.Usuario{}
+	if !Instance.Migrator().HasTable(usuario) || Instance.Migrator().HasIndex(usuario, "Usuario") {
+		return
+	}
+
+	var usuarios []model.Usuario
+	Instance.Unscoped().Select("id", "usuario").Order("id").Find(&usuarios)
+
+	vistos := make(map[string]bool, len(usuarios))
+	for _, usuario := range usuarios {
+		normalizado := model.NormalizarEmail(usuario.Usuario)
+		if vistos[normalizado] {
+			log.Printf("E-mail %q repetido; o Usuario %d passa a usar %q", usuario.Usuario, usuario.ID, fmt.Sprintf("%s#%d", normalizado, usuario.ID))
+			normalizado = fmt.Sprintf("%s#%d", normalizado, usuario.ID)
+		}
+		vistos[normalizado] = true
+
+		if normalizado != usuario.Usuario {
+			Instance.Unscoped().Model(&model.Usuario{}).Where("id = ?", usuario.ID).UpdateColumn("usuario", normalizado)
+		}
+	}
+}
+
+// preencherDescricoesNormalizadas prepara o índice único de Temas nas tabelas já existentes.
+// Temas antigos que só diferem por acentos ou caixa recebem o id como sufixo e continuam
+// disponíveis para serem mesclados.
+func preencherDescricoesNormalizadas() {
+	tema := &model.Tema{}
+	if !Instance.Migrator().HasTable(tema) {
+		return
+	}
+	if !Instance.Migrator().HasColumn(tema, "DescricaoNormalizada") {
+		Instance.Migrator().AddColumn(tema, "DescricaoNormalizada")
+	}
+
+	var temas []model.Tema
+	Instance.Select("id", "descricao").Where("descricao_normalizada = '' OR descricao_normalizada IS NULL").Find(&temas)
+
+	for _, tema := range temas {
+		normalizada := model.NormalizarTexto(tema.Descricao)
+
+		var total int64
+		Instance.Model(&model.Tema{}).Where("descricao_normalizada = ?", normalizada).Count(&total)
+		if total > 0 {
+			normalizada = fmt.Sprintf("%s#%d", normalizada, tema.ID)
+		}
+
+		Instance.Model(&model.Tema{}).Where("id = ?", tema.ID).UpdateColumn("descricao_normalizada", normalizada)
+	}
+}
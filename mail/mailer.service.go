@@ -0,0 +1,173 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Mensagem é um e-mail com versões em texto e em HTML
+type Mensagem struct {
+	Para       string
+	Assunto    string
+	Texto      string
+	HTML       string
+	Cabecalhos map[string]string
+}
+
+// Mailer envia Mensagens
+type Mailer interface {
+	Enviar(mensagem Mensagem) error
+}
+
+// Instance é o Mailer usado pelo servidor
+var Instance Mailer
+
+// Config escolhe e configura o Mailer
+type Config struct {
+	Tipo      string
+	Remetente string
+	Diretorio string
+	Host      string
+	Porta     int
+	Usuario   string
+	Senha     string
+}
+
+// New cria um ArquivoMailer ou, se o tipo for "smtp", um SMTPMailer
+func New(config Config) Mailer {
+
+	if config.Tipo == "smtp" {
+		return &SMTPMailer{Remetente: config.Remetente, Host: config.Host, Porta: config.Porta, Usuario: config.Usuario, Senha: config.Senha}
+	}
+
+	return &ArquivoMailer{Remetente: config.Remetente, Diretorio: config.Diretorio}
+}
+
+// ArquivoMailer grava cada Mensagem como um arquivo .eml no diretório, em vez de enviá-la.
+// Serve para desenvolvimento e testes.
+type ArquivoMailer struct {
+	Remetente string
+	Diretorio string
+}
+
+func (m *ArquivoMailer) Enviar(mensagem Mensagem) error {
+
+	if err := os.MkdirAll(m.Diretorio, 0o755); err != nil {
+		return err
+	}
+
+	conteudo, err := mensagem.Bytes(m.Remetente)
+	if err != nil {
+		return err
+	}
+
+	nome := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000000"), aleatorio(4))
+	return os.WriteFile(filepath.Join(m.Diretorio, nome), conteudo, 0o644)
+}
+
+// SMTPMailer envia as Mensagens por um servidor SMTP
+type SMTPMailer struct {
+	Remetente string
+	Host      string
+	Porta     int
+	Usuario   string
+	Senha     string
+}
+
+func (m *SMTPMailer) Enviar(mensagem Mensagem) error {
+
+	conteudo, err := mensagem.Bytes(m.Remetente)
+	if err != nil {
+		return err
+	}
+
+	var autenticacao smtp.Auth
+	if m.Usuario != "" {
+		autenticacao = smtp.PlainAuth("", m.Usuario, m.Senha, m.Host)
+	}
+
+	return smtp.SendMail(fmt.Sprintf("%s:%d", m.Host, m.Porta), autenticacao, m.Remetente, []string{mensagem.Para}, conteudo)
+}
+
+// Bytes monta a Mensagem no formato MIME, com as partes texto e HTML em multipart/alternative
+func (mensagem Mensagem) Bytes(remetente string) ([]byte, error) {
+
+	var conteudo bytes.Buffer
+	partes := multipart.NewWriter(&conteudo)
+
+	cabecalhos := map[string]string{
+		"From":         remetente,
+		"To":           mensagem.Para,
+		"Subject":      mime.QEncoding.Encode("utf-8", mensagem.Assunto),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"Message-ID":   fmt.Sprintf("<%s@%s>", aleatorio(16), dominio(remetente)),
+		"MIME-Version": "1.0",
+		"Content-Type": "multipart/alternative; boundary=" + partes.Boundary(),
+	}
+	for nome, valor := range mensagem.Cabecalhos {
+		cabecalhos[nome] = valor
+	}
+
+	var nomes []string
+	for nome := range cabecalhos {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+
+	var email bytes.Buffer
+	for _, nome := range nomes {
+		fmt.Fprintf(&email, "%s: %s\r\n", nome, cabecalhos[nome])
+	}
+	email.WriteString("\r\n")
+
+	for _, parte := range []struct{ tipo, corpo string }{{"text/plain", mensagem.Texto}, {"text/html", mensagem.HTML}} {
+		if parte.corpo == "" {
+			continue
+		}
+		escritor, err := partes.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {parte.tipo + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(escritor)
+		if _, err := qp.Write([]byte(parte.corpo)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := partes.Close(); err != nil {
+		return nil, err
+	}
+
+	email.Write(conteudo.Bytes())
+	return email.Bytes(), nil
+}
+
+func aleatorio(tamanho int) string {
+	valor := make([]byte, tamanho)
+	rand.Read(valor)
+	return hex.EncodeToString(valor)
+}
+
+func dominio(endereco string) string {
+	if i := strings.LastIndex(endereco, "@"); i >= 0 {
+		return strings.Trim(endereco[i+1:], "> ")
+	}
+	return "localhost"
+}
@@ -2,11 +2,17 @@ package main
 
 import (
 	"blogpessoal/auth"
+	"blogpessoal/cache"
 	"blogpessoal/controllers"
 	"blogpessoal/database"
+	"blogpessoal/mail"
+	"blogpessoal/model"
+	"blogpessoal/services"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
@@ -40,6 +46,93 @@ func main() {
 	database.Connect(AppConfig.ConnectionString)
 	database.Migrate()
 
+	// Initialize the mailer
+	mail.Instance = mail.New(mail.Config{
+		Tipo:      AppConfig.Mailer,
+		Remetente: AppConfig.MailerRemetente,
+		Diretorio: AppConfig.MailerDiretorio,
+		Host:      AppConfig.SMTPHost,
+		Porta:     AppConfig.SMTPPorta,
+		Usuario:   AppConfig.SMTPUsuario,
+		Senha:     AppConfig.SMTPSenha,
+	})
+
+	// Configure the password hashing algorithm
+	services.HashSenhas = services.ConfigHashSenha{
+		Algoritmo:         AppConfig.HashSenhaAlgoritmo,
+		BcryptCusto:       AppConfig.BcryptCusto,
+		Argon2Memoria:     AppConfig.Argon2Memoria,
+		Argon2Iteracoes:   AppConfig.Argon2Iteracoes,
+		Argon2Paralelismo: AppConfig.Argon2Paralelismo,
+	}
+	if err := services.HashSenhas.Validar(); err != nil {
+		log.Fatalf("Configuração do hash de senhas inválida: %s", err)
+	}
+
+	// Run a command line subcommand instead of the server, if one was given
+	if len(os.Args) > 1 {
+		RunCommand(os.Args[1:])
+		return
+	}
+
+	// Initialize the response cache
+	cache.Instance = cache.New(AppConfig.CacheMaxEntradas)
+	cache.RegisterCallbacks(database.Instance)
+
+	// Start the trash purge job
+	services.RetencaoLixeira = time.Duration(AppConfig.LixeiraRetencaoDias) * 24 * time.Hour
+	services.IniciarLimpezaLixeira(database.Instance, time.Hour)
+
+	// Configure how new users can register
+	if !services.ModoCadastroValido(AppConfig.CadastroModo) {
+		log.Fatalf("cadastro_modo inválido: %q (use %v)", AppConfig.CadastroModo, services.ModosCadastro)
+	}
+	services.ModoCadastro = AppConfig.CadastroModo
+
+	// Configure the password policy and the breached password list
+	services.PoliticaSenhas = services.PoliticaSenha{
+		TamanhoMinimo:        AppConfig.SenhaTamanhoMinimo,
+		ExigirMaiuscula:      AppConfig.SenhaExigirMaiuscula,
+		ExigirMinuscula:      AppConfig.SenhaExigirMinuscula,
+		ExigirNumero:         AppConfig.SenhaExigirNumero,
+		ExigirSimbolo:        AppConfig.SenhaExigirSimbolo,
+		ProibirDadosPessoais: AppConfig.SenhaProibirDadosPessoais,
+	}
+	if AppConfig.SenhasVazadasArquivo != "" {
+		lista, err := services.CarregarSenhasVazadas(AppConfig.SenhasVazadasArquivo)
+		if err != nil {
+			log.Fatalf("Erro ao carregar a lista de senhas vazadas: %s", err)
+		}
+		services.SenhasVazadas = lista
+	}
+
+	// Configure the password reset links sent when an admin forces a reset
+	if AppConfig.RedefinicaoSenhaHoras <= 0 {
+		log.Fatalf("redefinicao_senha_horas inválido: %d", AppConfig.RedefinicaoSenhaHoras)
+	}
+	services.RedefinicaoSenha = services.OpcoesRedefinicaoSenha{
+		Titulo:   AppConfig.TituloSite,
+		URLAPI:   AppConfig.URLAPI,
+		Validade: time.Duration(AppConfig.RedefinicaoSenhaHoras) * time.Hour,
+	}
+
+	// Start the account deletion job
+	if AppConfig.ExclusaoContaPolitica != services.ExclusaoApagar && AppConfig.ExclusaoContaPolitica != services.ExclusaoAnonimizar {
+		log.Fatalf("exclusao_conta_politica inválida: %q (use %q ou %q)", AppConfig.ExclusaoContaPolitica, services.ExclusaoApagar, services.ExclusaoAnonimizar)
+	}
+	services.CarenciaExclusaoConta = time.Duration(AppConfig.ExclusaoContaDias) * 24 * time.Hour
+	services.PoliticaExclusaoConta = AppConfig.ExclusaoContaPolitica
+	services.IniciarExclusaoContas(database.Instance, time.Hour)
+
+	// Start the email digest job
+	services.IniciarEnvioResumos(database.Instance, mail.Instance, opcoesResumo(), time.Hour)
+
+	// Start the expired sessions purge job
+	services.IniciarLimpezaSessoes(database.Instance, time.Hour)
+
+	// Start the webhook delivery worker
+	services.IniciarEntregaWebhooks(database.Instance, 15*time.Second)
+
 	// Initialize the router
 	router := mux.NewRouter().StrictSlash(true)
 
@@ -47,6 +140,12 @@ func main() {
 	RegisterPostagemRoutes(router)
 	RegisterTemaRoutes(router)
 	RegisterUsuarioRoutes(router)
+	RegisterAutorRoutes(router)
+	RegisterSeguidorRoutes(router)
+	RegisterNotificacaoRoutes(router)
+	RegisterResumoRoutes(router)
+	RegisterLixeiraRoutes(router)
+	RegisterAdminRoutes(router)
 	RegisterSwaggerRoutes(router)
 	//handler := cors.Default().Handler(router)
 
@@ -55,32 +154,131 @@ func main() {
 	log.Fatal(http.ListenAndServe(fmt.Sprintf("127.0.0.1:%v", AppConfig.Port), router))
 }
 
+// Tabelas que invalidam as respostas em cache de cada recurso
+var (
+	tabelasPostagem = []string{model.Postagem{}.TableName(), model.Tema{}.TableName(), model.Usuario{}.TableName()}
+	tabelasTema     = []string{model.Tema{}.TableName(), model.Postagem{}.TableName(), model.TemaRedirecionamento{}.TableName()}
+	tabelasUsuario  = []string{model.Usuario{}.TableName(), model.Postagem{}.TableName()}
+	tabelasSeguidor = []string{model.SeguidorUsuario{}.TableName(), model.SeguidorTema{}.TableName(), model.Usuario{}.TableName(), model.Tema{}.TableName()}
+	tabelasFeed     = append([]string{model.SeguidorUsuario{}.TableName(), model.SeguidorTema{}.TableName()}, tabelasPostagem...)
+)
+
 func RegisterPostagemRoutes(router *mux.Router) {
-	router.HandleFunc("/postagens", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.GetPostagens))).Methods("GET")
-	router.HandleFunc("/postagens/{id}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.GetPostagemById))).Methods("GET")
-	router.HandleFunc("/postagens/titulo/{titulo}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.GetPostagemByTitulo))).Methods("GET")
+	router.HandleFunc("/postagens", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetPostagens, tabelasPostagem...)))).Methods("GET")
+	router.HandleFunc("/postagens/{id}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetPostagemById, tabelasPostagem...)))).Methods("GET")
+	router.HandleFunc("/postagens/titulo/{titulo}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetPostagemByTitulo, tabelasPostagem...)))).Methods("GET")
 	router.HandleFunc("/postagens", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.CreatePostagem))).Methods("POST")
 	router.HandleFunc("/postagens", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.UpdatePostagem))).Methods("PUT")
 	router.HandleFunc("/postagens/{id}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.DeletePostagem))).Methods("DELETE")
 }
 
 func RegisterTemaRoutes(router *mux.Router) {
-	router.HandleFunc("/temas", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.GetTemas))).Methods("GET")
+	router.HandleFunc("/temas", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetTemas, tabelasTema...)))).Methods("GET")
 	router.HandleFunc("/temas", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.CreateTema))).Methods("POST")
-	router.HandleFunc("/temas/{id}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.GetTemaById))).Methods("GET")
-	router.HandleFunc("/temas/descricao/{descricao}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.GetTemaByDescricao))).Methods("GET")
+	router.HandleFunc("/temas/estatisticas", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetTemaEstatisticas, tabelasPostagem...)))).Methods("GET")
+	router.HandleFunc("/temas/arvore", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetTemaArvore, tabelasTema...)))).Methods("GET")
+	router.HandleFunc("/temas/{id}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetTemaById, tabelasTema...)))).Methods("GET")
+	router.HandleFunc("/temas/{id}/postagens", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetPostagensByTema, tabelasPostagem...)))).Methods("GET")
+	router.HandleFunc("/temas/slug/{slug}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetTemaBySlug, tabelasTema...)))).Methods("GET")
+	router.HandleFunc("/temas/{id}/mesclar", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.MergeTema))).Methods("POST")
+	router.HandleFunc("/temas/descricao/{descricao}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetTemaByDescricao, tabelasTema...)))).Methods("GET")
 	router.HandleFunc("/temas", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.UpdateTema))).Methods("PUT")
 	router.HandleFunc("/temas/{id}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.DeleteTema))).Methods("DELETE")
 }
 
 func RegisterUsuarioRoutes(router *mux.Router) {
-	router.HandleFunc("/usuarios/all", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.GetUsuarios))).Methods("GET")
+	router.HandleFunc("/usuarios/eu", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetPerfil, tabelasUsuario...)))).Methods("GET")
+	router.HandleFunc("/usuarios/eu", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.UpdatePerfil))).Methods("PUT")
+	router.HandleFunc("/usuarios/eu/senha", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.UpdateSenha))).Methods("PUT")
+	router.HandleFunc("/usuarios/eu/exportacao", auth.SetMiddlewareAuthentication(controllers.GetExportacao)).Methods("GET")
+	router.HandleFunc("/usuarios/eu/exclusao", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.RequestExclusaoConta))).Methods("POST")
+	router.HandleFunc("/usuarios/eu/exclusao", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.CancelExclusaoConta))).Methods("DELETE")
+	router.HandleFunc("/usuarios/sessoes", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.GetSessoes))).Methods("GET")
+	router.HandleFunc("/usuarios/sessoes", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.RevokeOutrasSessoes))).Methods("DELETE")
+	router.HandleFunc("/usuarios/sessoes/{id}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.RevokeSessao))).Methods("DELETE")
+	router.HandleFunc("/usuarios/all", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetUsuarios, tabelasUsuario...)))).Methods("GET")
+	router.HandleFunc("/usuarios/senha/redefinir", controllers.GetRedefinicaoSenha).Methods("GET")
+	router.HandleFunc("/usuarios/senha/redefinir", controllers.RedefineSenha).Methods("POST")
+	router.HandleFunc("/usuarios/cadastro", auth.SetMiddlewareJSON(controllers.GetModoCadastro)).Methods("GET")
 	router.HandleFunc("/usuarios/cadastrar", auth.SetMiddlewareJSON(controllers.CreateUsuario)).Methods("POST")
-	router.HandleFunc("/usuarios/{id}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.GetUsuarioById))).Methods("GET")
-	router.HandleFunc("/usuarios/atualizar", auth.SetMiddlewareJSON(controllers.UpdateUsuario)).Methods("PUT")
+	router.HandleFunc("/usuarios/{id}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetUsuarioById, tabelasUsuario...)))).Methods("GET")
+	router.HandleFunc("/usuarios/atualizar", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.UpdateUsuario)))).Methods("PUT")
 	router.HandleFunc("/usuarios/logar", auth.SetMiddlewareJSON(controllers.Authetication)).Methods("POST")
 }
 
+func RegisterAutorRoutes(router *mux.Router) {
+	router.HandleFunc("/autores/{slug}", auth.SetMiddlewareJSON(cache.SetMiddlewareCache(controllers.GetAutor, tabelasPostagem...))).Methods("GET")
+}
+
+func RegisterSeguidorRoutes(router *mux.Router) {
+	router.HandleFunc("/usuarios/{id}/seguir", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.FollowUsuario))).Methods("POST")
+	router.HandleFunc("/usuarios/{id}/seguir", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.UnfollowUsuario))).Methods("DELETE")
+	router.HandleFunc("/usuarios/{id}/seguidores", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetSeguidoresUsuario, tabelasSeguidor...)))).Methods("GET")
+	router.HandleFunc("/usuarios/{id}/seguindo", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetSeguindoUsuario, tabelasSeguidor...)))).Methods("GET")
+	router.HandleFunc("/temas/{id}/seguir", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.FollowTema))).Methods("POST")
+	router.HandleFunc("/temas/{id}/seguir", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.UnfollowTema))).Methods("DELETE")
+	router.HandleFunc("/temas/{id}/seguidores", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetSeguidoresTema, tabelasSeguidor...)))).Methods("GET")
+	router.HandleFunc("/feed", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(cache.SetMiddlewareCache(controllers.GetFeed, tabelasFeed...)))).Methods("GET")
+}
+
+func RegisterNotificacaoRoutes(router *mux.Router) {
+	router.HandleFunc("/notificacoes", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.GetNotificacoes))).Methods("GET")
+	router.HandleFunc("/notificacoes/nao-lidas", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.GetNotificacoesNaoLidas))).Methods("GET")
+	router.HandleFunc("/notificacoes/stream", auth.SetMiddlewareAuthentication(controllers.StreamNotificacoes)).Methods("GET")
+	router.HandleFunc("/notificacoes/lidas", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.MarkNotificacoesLidas))).Methods("POST")
+	router.HandleFunc("/notificacoes/{id}/lida", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.MarkNotificacaoLida))).Methods("POST")
+}
+
+func RegisterResumoRoutes(router *mux.Router) {
+	router.HandleFunc("/resumos/preferencias", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.GetPreferenciaResumo))).Methods("GET")
+	router.HandleFunc("/resumos/preferencias", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.UpdatePreferenciaResumo))).Methods("PUT")
+	router.HandleFunc("/resumos/cancelar", controllers.GetCancelResumo).Methods("GET")
+	router.HandleFunc("/resumos/cancelar", controllers.CancelResumo).Methods("POST")
+}
+
+func RegisterLixeiraRoutes(router *mux.Router) {
+	router.HandleFunc("/lixeira", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.GetLixeira))).Methods("GET")
+	router.HandleFunc("/lixeira/postagens/{id}/restaurar", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(controllers.RestorePostagem))).Methods("POST")
+	router.HandleFunc("/admin/lixeira", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.GetLixeiraAdmin)))).Methods("GET")
+	router.HandleFunc("/admin/lixeira/temas/{id}/restaurar", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.RestoreTema)))).Methods("POST")
+	router.HandleFunc("/admin/lixeira/usuarios/{id}/restaurar", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.RestoreUsuario)))).Methods("POST")
+}
+
+func RegisterAdminRoutes(router *mux.Router) {
+	router.HandleFunc("/admin/export", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.ExportPostagens)))).Methods("GET")
+	router.HandleFunc("/admin/import", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.ImportPostagens)))).Methods("POST")
+	router.HandleFunc("/admin/import/wxr", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.ImportWXR)))).Methods("POST")
+	router.HandleFunc("/admin/usuarios", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.GetAdminUsuarios)))).Methods("GET")
+	router.HandleFunc("/admin/usuarios/{id}/atividade", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.GetAtividadeUsuario)))).Methods("GET")
+	router.HandleFunc("/admin/usuarios/{id}/suspender", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.SuspendUsuario)))).Methods("POST")
+	router.HandleFunc("/admin/usuarios/{id}/banir", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.BanUsuario)))).Methods("POST")
+	router.HandleFunc("/admin/usuarios/{id}/reativar", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.ReactivateUsuario)))).Methods("POST")
+	router.HandleFunc("/admin/usuarios/{id}/perfil", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.ChangeUsuarioPerfil)))).Methods("PUT")
+	router.HandleFunc("/admin/usuarios/{id}/aprovar", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.ApproveUsuario)))).Methods("POST")
+	router.HandleFunc("/admin/usuarios/{id}/rejeitar", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.RejectUsuario)))).Methods("POST")
+	router.HandleFunc("/admin/cadastros/pendentes", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.GetCadastrosPendentes)))).Methods("GET")
+	router.HandleFunc("/admin/convites", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.GetConvites)))).Methods("GET")
+	router.HandleFunc("/admin/convites", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.CreateConvite)))).Methods("POST")
+	router.HandleFunc("/admin/convites/{id}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.DeleteConvite)))).Methods("DELETE")
+	router.HandleFunc("/admin/usuarios/{id}/redefinir-senha", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.ForceRedefinicaoSenha)))).Methods("POST")
+	router.HandleFunc("/admin/webhooks", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.GetWebhooks)))).Methods("GET")
+	router.HandleFunc("/admin/webhooks", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.CreateWebhook)))).Methods("POST")
+	router.HandleFunc("/admin/webhooks", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.UpdateWebhook)))).Methods("PUT")
+	router.HandleFunc("/admin/webhooks/{id}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.GetWebhookById)))).Methods("GET")
+	router.HandleFunc("/admin/webhooks/{id}", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.DeleteWebhook)))).Methods("DELETE")
+	router.HandleFunc("/admin/webhooks/{id}/entregas", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.GetEntregasWebhook)))).Methods("GET")
+	router.HandleFunc("/admin/webhooks/entregas/{id}/reenviar", auth.SetMiddlewareJSON(auth.SetMiddlewareAuthentication(auth.SetMiddlewareAdmin(controllers.ReplayEntregaWebhook)))).Methods("POST")
+}
+
+// opcoesResumo monta as opções do resumo por e-mail a partir da configuração
+func opcoesResumo() services.OpcoesResumo {
+	return services.OpcoesResumo{
+		Titulo: AppConfig.TituloSite,
+		URL:    AppConfig.URLSite,
+		URLAPI: AppConfig.URLAPI,
+	}
+}
+
 func RegisterSwaggerRoutes(router *mux.Router) {
 	router.PathPrefix("/").Handler(httpSwagger.WrapHandler)
 
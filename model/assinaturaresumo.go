@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Frequências do resumo de Postagens enviado por e-mail
+const (
+	FrequenciaNunca   = "nunca"
+	FrequenciaDiaria  = "diaria"
+	FrequenciaSemanal = "semanal"
+)
+
+// AssinaturaResumo guarda a preferência do Usuario pelo resumo de novas Postagens
+// e o token do link de cancelamento
+type AssinaturaResumo struct {
+	UsuarioID   uint       `gorm:"column:usuario_id;primaryKey;autoIncrement:false" json:"-"`
+	Usuario     Usuario    `gorm:"ForeignKey:UsuarioID;constraint:OnDelete:CASCADE;" json:"-"`
+	Frequencia  string     `gorm:"size:10;not null;default:semanal" json:"frequencia" validate:"required,oneof=nunca diaria semanal" example:"semanal"`
+	UltimoEnvio *time.Time `json:"ultimo_envio,omitempty"`
+	Token       string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+}
+
+func (AssinaturaResumo) TableName() string {
+	return "tb_assinaturas_resumo"
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Convite permite o cadastro de novos Usuarios quando o blog não está aberto a todos
+type Convite struct {
+	ID         uint       `gorm:"primary_key, AUTO_INCREMENT" json:"id"`
+	Codigo     string     `gorm:"size:32;not null;uniqueIndex" json:"codigo" example:"7F3A9C2B51D4"`
+	Observacao string     `gorm:"size:255" json:"observacao,omitempty" example:"Equipe de marketing"`
+	LimiteUsos int        `gorm:"not null;default:1" json:"limite_usos" example:"5"`
+	Usos       int        `gorm:"not null;default:0" json:"usos"`
+	ExpiraEm   *time.Time `json:"expira_em,omitempty"`
+	CriadorID  *uint      `gorm:"column:criador_id" json:"criador_id,omitempty"`
+	CreatedAt  time.Time  `json:"criado_em"`
+	// Indica se o Convite ainda pode ser usado, preenchido apenas nas respostas
+	Disponivel bool `gorm:"-" json:"disponivel"`
+}
+
+func (Convite) TableName() string {
+	return "tb_convites"
+}
+
+// DisponivelEm informa se o Convite não expirou e ainda tem usos
+func (convite Convite) DisponivelEm(agora time.Time) bool {
+	return convite.Usos < convite.LimiteUsos && (convite.ExpiraEm == nil || agora.Before(*convite.ExpiraEm))
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Tipos de Notificacao
+const (
+	NotificacaoNovoSeguidor = "novo_seguidor"
+	NotificacaoNovaPostagem = "nova_postagem"
+	// Enviada aos administradores quando um cadastro aguarda aprovação
+	NotificacaoCadastroPendente = "cadastro_pendente"
+)
+
+// Notificacao avisa um Usuario sobre algo que aconteceu no blog
+type Notificacao struct {
+	ID         uint       `gorm:"primary_key, AUTO_INCREMENT" json:"id"`
+	UsuarioID  uint       `gorm:"column:usuario_id;not null;index:idx_notificacoes_usuario_lida" json:"-"`
+	Usuario    Usuario    `gorm:"ForeignKey:UsuarioID;constraint:OnDelete:CASCADE;" json:"-"`
+	Tipo       string     `gorm:"size:30;not null" json:"tipo" example:"nova_postagem"`
+	Mensagem   string     `gorm:"size:255;not null" json:"mensagem"`
+	AutorID    *uint      `gorm:"column:autor_id" json:"autor_id,omitempty"`
+	PostagemID *uint      `gorm:"column:postagem_id" json:"postagem_id,omitempty"`
+	TemaID     *uint      `gorm:"column:tema_id" json:"tema_id,omitempty"`
+	Lida       bool       `gorm:"not null;default:false;index:idx_notificacoes_usuario_lida" json:"lida"`
+	LidaEm     *time.Time `json:"lida_em,omitempty"`
+	CreatedAt  time.Time  `json:"criada_em"`
+}
+
+func (Notificacao) TableName() string {
+	return "tb_notificacoes"
+}
@@ -1,20 +1,70 @@
 package model
 
 import (
+	"database/sql"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// Situações de uma Postagem
+const (
+	StatusPublicado = "publicado"
+	StatusRascunho  = "rascunho"
 )
 
 type Postagem struct {
 	ID        uint      `gorm:"primary_key, AUTO_INCREMENT" json:"id" example:"1"`
 	Titulo    string    `gorm:"not null;size:100" json:"titulo" validate:"required,min=5,max=100" example:"Minha primeira postagem"`
-	Texto     string    `gorm:"not null;size:1000" json:"texto" validate:"required,min=10,max=1000" example:"Texto da primeira postagem"`
+	Texto     string    `gorm:"not null;type:mediumtext" json:"texto" validate:"required,min=10,max=100000" example:"Texto da primeira postagem"`
+	Slug      string    `gorm:"size:100;uniqueIndex" json:"slug" validate:"omitempty,max=100" example:"minha-primeira-postagem"`
+	Status    string    `gorm:"size:20;not null;default:publicado" json:"status" validate:"omitempty,oneof=publicado rascunho" example:"publicado"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
 	UpdatedAt time.Time `gorm:"column:data;autoUpdateTime:mili" json:"data" example:"2022-04-09T21:21:46+00:00"`
+	// Identificador do item de origem nas importações do WordPress (guid ou post_id), usado para
+	// reconhecer a mesma Postagem ao importar o arquivo de novo
+	OrigemWXR *string `gorm:"column:origem_wxr;size:255;uniqueIndex" json:"-" swaggerignore:"true"`
+	// Momento em que a Postagem foi publicada no blog pela primeira vez, usado pelo resumo por e-mail
+	PublicadaEm *time.Time `gorm:"column:publicada_em;index" json:"-" swaggerignore:"true"`
 	TemaID    uint      `gorm:"column:tema_id;not null" json:"tema_id" validate:"required" example:"1"`
 	Tema      Tema      `gorm:"ForeignKey:TemaID;association_foreignkey:ID" json:"tema" validate:"-"`
 	UsuarioID uint      `gorm:"column:usuario_id;not null" json:"usuario_id" validate:"required" example:"1"`
 	Usuario   Usuario   `gorm:"ForeignKey:UsuarioID;association_foreignkey:ID" json:"usuario" validate:"-"`
+	// Caminho do Tema raiz até o Tema da Postagem, preenchido apenas nas respostas
+	Breadcrumbs []TemaResumo `gorm:"-" json:"breadcrumbs,omitempty" validate:"-"`
 }
- 
+
 func (Postagem) TableName() string {
 	return "tb_postagens"
 }
+
+// BeforeSave normaliza o slug ou o gera a partir do título quando ele não é informado
+func (postagem *Postagem) BeforeSave(tx *gorm.DB) error {
+	postagem.Slug = GerarSlugUnico(tx, postagem.TableName(), postagem.Slug, postagem.Titulo, postagem.ID)
+	if postagem.Status == "" {
+		postagem.Status = StatusPublicado
+	}
+	if postagem.Status == StatusPublicado && postagem.PublicadaEm == nil {
+		postagem.PublicadaEm = publicacaoAnterior(tx, postagem.ID)
+		if postagem.PublicadaEm == nil {
+			agora := time.Now()
+			postagem.PublicadaEm = &agora
+		}
+	}
+	return nil
+}
+
+// publicacaoAnterior mantém a data da primeira publicação quando a Postagem é salva
+// a partir de uma requisição que não a traz
+func publicacaoAnterior(tx *gorm.DB, id uint) *time.Time {
+	if id == 0 {
+		return nil
+	}
+
+	var publicadaEm sql.NullTime
+	tx.Session(&gorm.Session{NewDB: true}).Unscoped().Table(Postagem{}.TableName()).Where("id = ?", id).Select("publicada_em").Row().Scan(&publicadaEm)
+	if !publicadaEm.Valid {
+		return nil
+	}
+	return &publicadaEm.Time
+}
@@ -0,0 +1,81 @@
+package model
+
+import "time"
+
+// PostagemRequisicao é o corpo da criação e da atualização de uma Postagem
+type PostagemRequisicao struct {
+	ID        uint   `json:"id" example:"1"`
+	Titulo    string `json:"titulo" validate:"required,min=5,max=100" example:"Minha primeira postagem"`
+	Texto     string `json:"texto" validate:"required,min=10,max=100000" example:"Texto da primeira postagem"`
+	Slug      string `json:"slug" validate:"omitempty,max=100" example:"minha-primeira-postagem"`
+	Status    string `json:"status" validate:"omitempty,oneof=publicado rascunho" example:"publicado"`
+	TemaID    uint   `json:"tema_id" validate:"required" example:"1"`
+	UsuarioID uint   `json:"usuario_id" validate:"required" example:"1"`
+}
+
+// PostagemResposta é a Postagem enviada aos clientes, com o autor apenas pelo perfil público
+type PostagemResposta struct {
+	ID          uint           `json:"id" example:"1"`
+	Titulo      string         `json:"titulo" example:"Minha primeira postagem"`
+	Texto       string         `json:"texto" example:"Texto da primeira postagem"`
+	Slug        string         `json:"slug" example:"minha-primeira-postagem"`
+	Status      string         `json:"status" example:"publicado"`
+	Data        time.Time      `json:"data" example:"2022-04-09T21:21:46+00:00"`
+	TemaID      uint           `json:"tema_id" example:"1"`
+	Tema        *TemaResumo    `json:"tema,omitempty"`
+	UsuarioID   uint           `json:"usuario_id" example:"1"`
+	Usuario     *UsuarioResumo `json:"usuario,omitempty"`
+	Breadcrumbs []TemaResumo   `json:"breadcrumbs,omitempty"`
+}
+
+// Modelo cria a Postagem com os dados da requisição
+func (requisicao PostagemRequisicao) Modelo() Postagem {
+	return Postagem{
+		ID:        requisicao.ID,
+		Titulo:    requisicao.Titulo,
+		Texto:     requisicao.Texto,
+		Slug:      requisicao.Slug,
+		Status:    requisicao.Status,
+		TemaID:    requisicao.TemaID,
+		UsuarioID: requisicao.UsuarioID,
+	}
+}
+
+// Resposta converte a Postagem para o formato enviado aos clientes.
+// O Tema e o autor só são incluídos quando foram carregados.
+func (postagem Postagem) Resposta() PostagemResposta {
+
+	resposta := PostagemResposta{
+		ID:          postagem.ID,
+		Titulo:      postagem.Titulo,
+		Texto:       postagem.Texto,
+		Slug:        postagem.Slug,
+		Status:      postagem.Status,
+		Data:        postagem.UpdatedAt,
+		TemaID:      postagem.TemaID,
+		UsuarioID:   postagem.UsuarioID,
+		Breadcrumbs: postagem.Breadcrumbs,
+	}
+
+	if postagem.Tema.ID != 0 {
+		tema := postagem.Tema.Resumo()
+		resposta.Tema = &tema
+	}
+	if postagem.Usuario.ID != 0 {
+		autor := postagem.Usuario.Resumo()
+		resposta.Usuario = &autor
+	}
+
+	return resposta
+}
+
+// PostagensResposta converte uma lista de Postagens para o formato enviado aos clientes
+func PostagensResposta(postagens []Postagem) []PostagemResposta {
+
+	respostas := make([]PostagemResposta, len(postagens))
+	for i, postagem := range postagens {
+		respostas[i] = postagem.Resposta()
+	}
+
+	return respostas
+}
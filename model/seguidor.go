@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// SeguidorUsuario registra que um Usuario segue outro Usuario
+type SeguidorUsuario struct {
+	SeguidorID uint      `gorm:"column:seguidor_id;primaryKey;autoIncrement:false" json:"seguidor_id"`
+	Seguidor   Usuario   `gorm:"ForeignKey:SeguidorID;constraint:OnDelete:CASCADE;" json:"-"`
+	UsuarioID  uint      `gorm:"column:usuario_id;primaryKey;autoIncrement:false;index" json:"usuario_id"`
+	Usuario    Usuario   `gorm:"ForeignKey:UsuarioID;constraint:OnDelete:CASCADE;" json:"-"`
+	CreatedAt  time.Time `json:"criado_em"`
+}
+
+func (SeguidorUsuario) TableName() string {
+	return "tb_seguidores_usuarios"
+}
+
+// SeguidorTema registra que um Usuario segue um Tema
+type SeguidorTema struct {
+	UsuarioID uint      `gorm:"column:usuario_id;primaryKey;autoIncrement:false" json:"usuario_id"`
+	Usuario   Usuario   `gorm:"ForeignKey:UsuarioID;constraint:OnDelete:CASCADE;" json:"-"`
+	TemaID    uint      `gorm:"column:tema_id;primaryKey;autoIncrement:false;index" json:"tema_id"`
+	Tema      Tema      `gorm:"ForeignKey:TemaID;constraint:OnDelete:CASCADE;" json:"-"`
+	CreatedAt time.Time `json:"criado_em"`
+}
+
+func (SeguidorTema) TableName() string {
+	return "tb_seguidores_temas"
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Sessao registra cada login do Usuario. O token carrega o identificador da Sessao,
+// e apagar a Sessao invalida o token antes de ele expirar.
+type Sessao struct {
+	ID              uint      `gorm:"primary_key, AUTO_INCREMENT" json:"id"`
+	Identificador   string    `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	UsuarioID       uint      `gorm:"column:usuario_id;not null;index" json:"-"`
+	Usuario         Usuario   `gorm:"ForeignKey:UsuarioID;constraint:OnDelete:CASCADE;" json:"-"`
+	Dispositivo     string    `gorm:"size:100" json:"dispositivo" example:"Firefox no Linux"`
+	IP              string    `gorm:"size:45" json:"ip" example:"203.0.113.10"`
+	UserAgent       string    `gorm:"size:255" json:"user_agent"`
+	CreatedAt       time.Time `json:"criada_em"`
+	UltimaAtividade time.Time `json:"ultima_atividade"`
+	ExpiraEm        time.Time `gorm:"index" json:"expira_em"`
+	// Indica a Sessao do token usado na requisição, preenchido apenas nas respostas
+	Atual bool `gorm:"-" json:"atual"`
+}
+
+func (Sessao) TableName() string {
+	return "tb_sessoes"
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+const tamanhoMaximoSlug = 100
+
+var removerAcentos = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// NormalizarTexto remove acentos, caixa e espaços repetidos, para comparar nomes
+// que diferem apenas nesses detalhes: " Programação  Go" e "programacao go" são iguais
+func NormalizarTexto(texto string) string {
+	semAcentos, _, _ := transform.String(removerAcentos, texto)
+	return strings.Join(strings.Fields(strings.ToLower(semAcentos)), " ")
+}
+
+// GerarSlug converte um texto em um identificador para URLs: "Concorrência em Go" -> "concorrencia-em-go"
+func GerarSlug(texto string) string {
+
+	semAcentos, _, _ := transform.String(removerAcentos, texto)
+
+	var slug strings.Builder
+	hifen := false
+	for _, r := range strings.ToLower(semAcentos) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			slug.WriteRune(r)
+			hifen = false
+		} else if !hifen && slug.Len() > 0 {
+			slug.WriteRune('-')
+			hifen = true
+		}
+	}
+
+	resultado := strings.TrimRight(slug.String(), "-")
+	if len(resultado) > tamanhoMaximoSlug {
+		resultado = strings.TrimRight(resultado[:tamanhoMaximoSlug], "-")
+	}
+	return resultado
+}
+
+// GerarSlugUnico normaliza o slug informado ou, se vazio, mantém o slug já gravado do registro
+// ou gera um novo a partir do texto, acrescentando um sufixo numérico quando outro
+// registro da tabela já usa o mesmo slug
+func GerarSlugUnico(tx *gorm.DB, tabela string, slugInformado string, texto string, id uint) string {
+
+	base := GerarSlug(slugInformado)
+
+	if base == "" && id != 0 {
+		var atual string
+		tx.Session(&gorm.Session{NewDB: true}).Table(tabela).Where("id = ?", id).Select("slug").Scan(&atual)
+		if atual != "" {
+			return atual
+		}
+	}
+
+	if base == "" {
+		base = GerarSlug(texto)
+	}
+	if base == "" {
+		base = "item"
+	}
+
+	slug := base
+	for i := 2; ; i++ {
+		var total int64
+		tx.Session(&gorm.Session{NewDB: true}).Table(tabela).Where("slug = ? AND id <> ?", slug, id).Count(&total)
+		if total == 0 {
+			return slug
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
@@ -1,11 +1,41 @@
 package model
 
+import "gorm.io/gorm"
+
 type Tema struct {
 	ID        uint       `gorm:"primary_key, AUTO_INCREMENT" json:"id,omitempty"`
-	Descricao string     `gorm:"not null" json:"descricao,omitempty" validate:"required"`
-	Postagens []Postagem `gorm:"foreignkey:TemaID;references:ID;constraint:OnDelete:CASCADE;" json:"postagens,omitempty"`
+	Descricao            string     `gorm:"not null;size:100" json:"descricao,omitempty" validate:"required,max=100"`
+	DescricaoNormalizada string     `gorm:"size:100;uniqueIndex" json:"-"`
+	Slug                 string     `gorm:"size:100;uniqueIndex" json:"slug,omitempty" validate:"omitempty,max=100"`
+	Sobre                string     `gorm:"type:text" json:"sobre,omitempty" validate:"omitempty,max=5000"`
+	Cor                  string     `gorm:"size:7" json:"cor,omitempty" validate:"omitempty,hexcolor" example:"#1e88e5"`
+	Icone                string     `gorm:"size:50" json:"icone,omitempty" validate:"omitempty,max=50" example:"code"`
+	ImagemCapa           string     `gorm:"size:255" json:"imagem_capa,omitempty" validate:"omitempty,url,max=255"`
+	Ordem                int        `gorm:"not null;default:0" json:"ordem"`
+	DeletedAt            gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
+	ParentID             *uint      `gorm:"column:parent_id;index" json:"parent_id,omitempty"`
+	Subtemas             []Tema     `gorm:"foreignkey:ParentID;references:ID;constraint:OnDelete:SET NULL;" json:"subtemas,omitempty" validate:"-"`
+	Postagens            []Postagem `gorm:"foreignkey:TemaID;references:ID;constraint:OnDelete:RESTRICT;" json:"postagens,omitempty"`
+}
+
+// Descrição do Tema que recebe as Postagens de Temas apagados com a estratégia "sem_categoria"
+const DescricaoSemCategoria = "Sem Categoria"
+
+// TemaResumo identifica um Tema nas migalhas (breadcrumbs) das Postagens
+type TemaResumo struct {
+	ID        uint   `json:"id"`
+	Descricao string `json:"descricao"`
+	Slug      string `json:"slug"`
 }
 
 func (Tema) TableName() string {
 	return "tb_temas"
 }
+
+// BeforeSave normaliza o slug ou o gera a partir da descrição quando ele não é informado,
+// e grava a descrição normalizada usada no índice único
+func (tema *Tema) BeforeSave(tx *gorm.DB) error {
+	tema.DescricaoNormalizada = NormalizarTexto(tema.Descricao)
+	tema.Slug = GerarSlugUnico(tx, tema.TableName(), tema.Slug, tema.Descricao, tema.ID)
+	return nil
+}
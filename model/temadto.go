@@ -0,0 +1,83 @@
+package model
+
+// TemaRequisicao é o corpo da criação e da atualização de um Tema
+type TemaRequisicao struct {
+	ID         uint   `json:"id,omitempty" example:"1"`
+	Descricao  string `json:"descricao" validate:"required,max=100" example:"Programação"`
+	Slug       string `json:"slug,omitempty" validate:"omitempty,max=100" example:"programacao"`
+	Sobre      string `json:"sobre,omitempty" validate:"omitempty,max=5000"`
+	Cor        string `json:"cor,omitempty" validate:"omitempty,hexcolor" example:"#1e88e5"`
+	Icone      string `json:"icone,omitempty" validate:"omitempty,max=50" example:"code"`
+	ImagemCapa string `json:"imagem_capa,omitempty" validate:"omitempty,url,max=255"`
+	Ordem      int    `json:"ordem"`
+	ParentID   *uint  `json:"parent_id,omitempty"`
+}
+
+// TemaResposta é o Tema enviado aos clientes
+type TemaResposta struct {
+	ID         uint               `json:"id" example:"1"`
+	Descricao  string             `json:"descricao" example:"Programação"`
+	Slug       string             `json:"slug" example:"programacao"`
+	Sobre      string             `json:"sobre,omitempty"`
+	Cor        string             `json:"cor,omitempty" example:"#1e88e5"`
+	Icone      string             `json:"icone,omitempty" example:"code"`
+	ImagemCapa string             `json:"imagem_capa,omitempty"`
+	Ordem      int                `json:"ordem"`
+	ParentID   *uint              `json:"parent_id,omitempty"`
+	Subtemas   []TemaResposta     `json:"subtemas,omitempty"`
+	Postagens  []PostagemResposta `json:"postagens,omitempty"`
+}
+
+// Modelo cria o Tema com os dados da requisição
+func (requisicao TemaRequisicao) Modelo() Tema {
+	return Tema{
+		ID:         requisicao.ID,
+		Descricao:  requisicao.Descricao,
+		Slug:       requisicao.Slug,
+		Sobre:      requisicao.Sobre,
+		Cor:        requisicao.Cor,
+		Icone:      requisicao.Icone,
+		ImagemCapa: requisicao.ImagemCapa,
+		Ordem:      requisicao.Ordem,
+		ParentID:   requisicao.ParentID,
+	}
+}
+
+// Resumo identifica o Tema nas Postagens e nas listas
+func (tema Tema) Resumo() TemaResumo {
+	return TemaResumo{ID: tema.ID, Descricao: tema.Descricao, Slug: tema.Slug}
+}
+
+// Resposta converte o Tema, com os subtemas e as Postagens carregados, para o formato enviado aos clientes
+func (tema Tema) Resposta() TemaResposta {
+
+	resposta := TemaResposta{
+		ID:         tema.ID,
+		Descricao:  tema.Descricao,
+		Slug:       tema.Slug,
+		Sobre:      tema.Sobre,
+		Cor:        tema.Cor,
+		Icone:      tema.Icone,
+		ImagemCapa: tema.ImagemCapa,
+		Ordem:      tema.Ordem,
+		ParentID:   tema.ParentID,
+		Postagens:  PostagensResposta(tema.Postagens),
+	}
+
+	if len(tema.Subtemas) > 0 {
+		resposta.Subtemas = TemasResposta(tema.Subtemas)
+	}
+
+	return resposta
+}
+
+// TemasResposta converte uma lista de Temas para o formato enviado aos clientes
+func TemasResposta(temas []Tema) []TemaResposta {
+
+	respostas := make([]TemaResposta, len(temas))
+	for i, tema := range temas {
+		respostas[i] = tema.Resposta()
+	}
+
+	return respostas
+}
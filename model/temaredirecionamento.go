@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// TemaRedirecionamento aponta o slug de um Tema removido em uma mesclagem para o Tema que o absorveu
+type TemaRedirecionamento struct {
+	ID         uint      `gorm:"primary_key, AUTO_INCREMENT" json:"id"`
+	SlugAntigo string    `gorm:"size:100;not null;uniqueIndex" json:"slug_antigo"`
+	TemaID     uint      `gorm:"column:tema_id;not null;index" json:"tema_id"`
+	Tema       Tema      `gorm:"ForeignKey:TemaID;constraint:OnDelete:CASCADE;" json:"-"`
+	CreatedAt  time.Time `json:"criado_em"`
+}
+
+func (TemaRedirecionamento) TableName() string {
+	return "tb_temas_redirecionamentos"
+}
@@ -1,14 +1,104 @@
 package model
 
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// Perfis de acesso do Usuario
+const (
+	PerfilUsuario = "usuario"
+	PerfilAdmin   = "admin"
+)
+
+// Situações da conta do Usuario
+const (
+	SituacaoAtivo    = "ativo"
+	SituacaoSuspenso = "suspenso"
+	SituacaoBanido   = "banido"
+	// Cadastro aguardando a aprovação de um administrador
+	SituacaoPendente = "pendente"
+)
+
 type Usuario struct {
 	ID        uint       `gorm:"primary_key, AUTO_INCREMENT" json:"id,omitempty"`
 	Nome      string     `gorm:"not null" json:"nome,omitempty" validate:"required"`
-	Usuario   string     `gorm:"not null" json:"usuario,omitempty" validate:"required"`
-	Senha     string     `gorm:"not null, min=8" json:"senha,omitempty" validate:"required"`
+	// E-mail do Usuario, sempre gravado normalizado por NormalizarEmail
+	Usuario   string     `gorm:"size:255;not null;uniqueIndex" json:"usuario,omitempty" validate:"required,email"`
+	Senha     string     `gorm:"not null" json:"-" validate:"required"`
 	Foto      string     `json:"foto,omitempty"`
+	// Identificador público do autor, usado em /autores/{slug}
+	Slug      string     `gorm:"size:100;uniqueIndex" json:"slug,omitempty"`
+	Sobre     string     `gorm:"type:text" json:"sobre,omitempty"`
+	Site      string     `gorm:"size:255" json:"site,omitempty"`
+	Links     []string   `gorm:"serializer:json;type:text" json:"links,omitempty"`
+	Perfil    string     `gorm:"not null;default:usuario" json:"perfil,omitempty"`
+	// Versão gravada nos tokens; incrementá-la invalida os tokens emitidos antes
+	VersaoToken uint     `gorm:"not null;default:0" json:"-"`
+	// Moderação: a suspensão sem data de término vale até o Usuario ser reativado
+	Situacao        string     `gorm:"size:20;not null;default:ativo;index" json:"situacao,omitempty"`
+	MotivoSuspensao string     `gorm:"size:500" json:"motivo_suspensao,omitempty"`
+	SuspensoAte     *time.Time `json:"suspenso_ate,omitempty"`
+	// Obriga o Usuario a trocar a senha antes de usar a API
+	RedefinirSenha  bool       `gorm:"not null;default:false" json:"redefinir_senha,omitempty"`
+	// Hash SHA-256 do token de uso único enviado por e-mail para redefinir a senha
+	TokenRedefinicaoSenha    string     `gorm:"size:64;index" json:"-"`
+	RedefinicaoSenhaExpiraEm *time.Time `json:"-"`
+	UltimoLogin     *time.Time `json:"ultimo_login,omitempty"`
+	// Data em que a conta, a pedido do Usuario, será excluída
+	ExclusaoAgendadaEm *time.Time `gorm:"index" json:"exclusao_agendada_em,omitempty"`
+	// Convite usado no cadastro, quando houver
+	ConviteID *uint `gorm:"column:convite_id;index" json:"-"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
 	Postagens []Postagem `gorm:"foreignkey:UsuarioID;references:ID;constraint:OnDelete:CASCADE;" json:"postagens,omitempty"`
 }
 
+// UsuarioResumo identifica um Usuario em listas públicas, sem dados de acesso
+type UsuarioResumo struct {
+	ID   uint   `json:"id"`
+	Nome string `json:"nome"`
+	Slug string `json:"slug,omitempty"`
+	Foto string `json:"foto,omitempty"`
+}
+
 func (Usuario) TableName() string {
 	return "tb_usuarios"
 }
+
+// SituacaoAtual retorna a situação da conta, considerando ativas as suspensões que já terminaram
+func (usuario Usuario) SituacaoAtual() string {
+	switch {
+	case usuario.Situacao == SituacaoBanido:
+		return SituacaoBanido
+	case usuario.Situacao == SituacaoPendente:
+		return SituacaoPendente
+	case usuario.Situacao == SituacaoSuspenso && (usuario.SuspensoAte == nil || time.Now().Before(*usuario.SuspensoAte)):
+		return SituacaoSuspenso
+	default:
+		return SituacaoAtivo
+	}
+}
+
+// Visivel informa se a conta aparece para os outros Usuarios e nas páginas públicas:
+// as banidas e as que aguardam aprovação ficam ocultas
+func (usuario Usuario) Visivel() bool {
+	return usuario.Situacao != SituacaoBanido && usuario.Situacao != SituacaoPendente
+}
+
+// BeforeSave normaliza o e-mail e o slug do autor, gerando o slug a partir do nome quando ele não é informado
+func (usuario *Usuario) BeforeSave(tx *gorm.DB) error {
+	if usuario.Usuario != "" {
+		usuario.Usuario = NormalizarEmail(usuario.Usuario)
+	}
+	usuario.Slug = GerarSlugUnico(tx, usuario.TableName(), usuario.Slug, usuario.Nome, usuario.ID)
+	return nil
+}
+
+// NormalizarEmail remove os espaços das pontas, converte para minúsculas e para a forma NFC do Unicode,
+// para que "Ana@X.com " e "ana@x.com" identifiquem o mesmo Usuario
+func NormalizarEmail(email string) string {
+	return norm.NFC.String(strings.ToLower(strings.TrimSpace(email)))
+}
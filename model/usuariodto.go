@@ -0,0 +1,181 @@
+package model
+
+import "time"
+
+// UsuarioCadastro é o corpo do cadastro de um Usuario
+type UsuarioCadastro struct {
+	Nome    string `json:"nome" validate:"required" example:"Maria da Silva"`
+	Usuario string `json:"usuario" validate:"required,email,max=255" example:"maria@email.com"`
+	Senha   string `json:"senha" validate:"required" example:"12345678"`
+	Foto    string `json:"foto,omitempty" example:"https://i.imgur.com/foto.jpg"`
+	// Código do Convite, obrigatório quando o cadastro é apenas por convite
+	Convite string `json:"convite,omitempty" example:"7F3A9C2B51D4"`
+}
+
+// UsuarioAtualizacao é o corpo da atualização de um Usuario por um administrador.
+// A senha só é alterada pelo próprio Usuario, com AlteracaoSenha.
+type UsuarioAtualizacao struct {
+	ID      uint   `json:"id" validate:"required" example:"1"`
+	Nome    string `json:"nome" validate:"required" example:"Maria da Silva"`
+	Usuario string `json:"usuario" validate:"required,email,max=255" example:"maria@email.com"`
+	Foto    string `json:"foto,omitempty" example:"https://i.imgur.com/foto.jpg"`
+}
+
+// PerfilAtualizacao é o corpo da atualização do perfil do Usuario logado.
+// O e-mail identifica o Usuario nos tokens e não é alterado por aqui.
+type PerfilAtualizacao struct {
+	Nome  string   `json:"nome" validate:"required" example:"Maria da Silva"`
+	Foto  string   `json:"foto,omitempty" example:"https://i.imgur.com/foto.jpg"`
+	Slug  string   `json:"slug,omitempty" validate:"omitempty,max=100" example:"maria-da-silva"`
+	Sobre string   `json:"sobre,omitempty" validate:"omitempty,max=5000" example:"Desenvolvedora Go"`
+	Site  string   `json:"site,omitempty" validate:"omitempty,url,max=255" example:"https://maria.dev"`
+	Links []string `json:"links,omitempty" validate:"max=10,dive,url,max=255" example:"https://github.com/maria"`
+}
+
+// AlteracaoSenha é o corpo da troca de senha do Usuario logado
+type AlteracaoSenha struct {
+	SenhaAtual string `json:"senha_atual" validate:"required" example:"12345678"`
+	NovaSenha  string `json:"nova_senha" validate:"required" example:"nova-senha-123"`
+}
+
+// RedefinicaoSenha é o corpo da redefinição de senha com o token enviado por e-mail
+type RedefinicaoSenha struct {
+	Token     string `json:"token" validate:"required" example:"5f0c1e..."`
+	NovaSenha string `json:"nova_senha" validate:"required" example:"nova-senha-123"`
+}
+
+// ConfirmacaoSenha é o corpo das operações que exigem que o Usuario logado confirme a senha
+type ConfirmacaoSenha struct {
+	Senha string `json:"senha" validate:"required" example:"12345678"`
+}
+
+// UsuarioPublico é o perfil de um Usuario visto pelos outros Usuarios
+type UsuarioPublico struct {
+	ID        uint               `json:"id"`
+	Nome      string             `json:"nome"`
+	Slug      string             `json:"slug"`
+	Foto      string             `json:"foto,omitempty"`
+	Sobre     string             `json:"sobre,omitempty"`
+	Site      string             `json:"site,omitempty"`
+	Links     []string           `json:"links,omitempty"`
+	Postagens []PostagemResposta `json:"postagens,omitempty"`
+}
+
+// UsuarioProprio é o perfil que o Usuario vê de si mesmo, com os dados de acesso exceto a senha
+type UsuarioProprio struct {
+	ID      uint     `json:"id"`
+	Nome    string   `json:"nome"`
+	Usuario string   `json:"usuario"`
+	Slug    string   `json:"slug"`
+	Foto    string   `json:"foto,omitempty"`
+	Sobre   string   `json:"sobre,omitempty"`
+	Site    string   `json:"site,omitempty"`
+	Links   []string `json:"links,omitempty"`
+	Perfil  string   `json:"perfil"`
+	// ativo ou, enquanto o cadastro aguarda a aprovação de um administrador, pendente
+	Situacao string `json:"situacao"`
+	// Preenchida enquanto a exclusão da conta pedida pelo Usuario pode ser cancelada
+	ExclusaoAgendadaEm *time.Time         `json:"exclusao_agendada_em,omitempty"`
+	Postagens          []PostagemResposta `json:"postagens,omitempty"`
+}
+
+// UsuarioAdmin é o Usuario visto por um administrador
+type UsuarioAdmin struct {
+	ID                 uint               `json:"id"`
+	Nome               string             `json:"nome"`
+	Usuario            string             `json:"usuario"`
+	Slug               string             `json:"slug"`
+	Foto               string             `json:"foto,omitempty"`
+	Sobre              string             `json:"sobre,omitempty"`
+	Site               string             `json:"site,omitempty"`
+	Links              []string           `json:"links,omitempty"`
+	Perfil             string             `json:"perfil"`
+	Situacao           string             `json:"situacao"`
+	MotivoSuspensao    string             `json:"motivo_suspensao,omitempty"`
+	SuspensoAte        *time.Time         `json:"suspenso_ate,omitempty"`
+	RedefinirSenha     bool               `json:"redefinir_senha"`
+	UltimoLogin        *time.Time         `json:"ultimo_login,omitempty"`
+	ExclusaoAgendadaEm *time.Time         `json:"exclusao_agendada_em,omitempty"`
+	ConviteID          *uint              `json:"convite_id,omitempty"`
+	TotalPostagens     int64              `json:"total_postagens"`
+	Postagens          []PostagemResposta `json:"postagens,omitempty"`
+}
+
+// Modelo cria o Usuario com os dados do cadastro, no perfil padrão
+func (cadastro UsuarioCadastro) Modelo() Usuario {
+	return Usuario{Nome: cadastro.Nome, Usuario: NormalizarEmail(cadastro.Usuario), Senha: cadastro.Senha, Foto: cadastro.Foto, Perfil: PerfilUsuario}
+}
+
+// Aplicar copia os dados da atualização para o Usuario, sem alterar a senha e o perfil
+func (atualizacao UsuarioAtualizacao) Aplicar(usuario *Usuario) {
+	usuario.ID = atualizacao.ID
+	usuario.Nome = atualizacao.Nome
+	usuario.Usuario = NormalizarEmail(atualizacao.Usuario)
+	usuario.Foto = atualizacao.Foto
+}
+
+// Resumo identifica o Usuario como autor, sem dados de acesso
+func (usuario Usuario) Resumo() UsuarioResumo {
+	return UsuarioResumo{ID: usuario.ID, Nome: usuario.Nome, Slug: usuario.Slug, Foto: usuario.Foto}
+}
+
+// Publico retorna o perfil público do Usuario
+func (usuario Usuario) Publico() UsuarioPublico {
+	return UsuarioPublico{
+		ID:        usuario.ID,
+		Nome:      usuario.Nome,
+		Slug:      usuario.Slug,
+		Foto:      usuario.Foto,
+		Sobre:     usuario.Sobre,
+		Site:      usuario.Site,
+		Links:     usuario.Links,
+		Postagens: PostagensResposta(usuario.Postagens),
+	}
+}
+
+// Proprio retorna o perfil que o Usuario vê de si mesmo
+func (usuario Usuario) Proprio() UsuarioProprio {
+	return UsuarioProprio{
+		ID:                 usuario.ID,
+		Nome:               usuario.Nome,
+		Usuario:            usuario.Usuario,
+		Slug:               usuario.Slug,
+		Foto:               usuario.Foto,
+		Sobre:              usuario.Sobre,
+		Site:               usuario.Site,
+		Links:              usuario.Links,
+		Perfil:             usuario.Perfil,
+		Situacao:           usuario.SituacaoAtual(),
+		ExclusaoAgendadaEm: usuario.ExclusaoAgendadaEm,
+		Postagens:          PostagensResposta(usuario.Postagens),
+	}
+}
+
+// Admin retorna o Usuario como visto por um administrador
+func (usuario Usuario) Admin() UsuarioAdmin {
+	admin := UsuarioAdmin{
+		ID:                 usuario.ID,
+		Nome:               usuario.Nome,
+		Usuario:            usuario.Usuario,
+		Slug:               usuario.Slug,
+		Foto:               usuario.Foto,
+		Sobre:              usuario.Sobre,
+		Site:               usuario.Site,
+		Links:              usuario.Links,
+		Perfil:             usuario.Perfil,
+		Situacao:           usuario.SituacaoAtual(),
+		RedefinirSenha:     usuario.RedefinirSenha,
+		UltimoLogin:        usuario.UltimoLogin,
+		ExclusaoAgendadaEm: usuario.ExclusaoAgendadaEm,
+		ConviteID:          usuario.ConviteID,
+		TotalPostagens:     int64(len(usuario.Postagens)),
+		Postagens:          PostagensResposta(usuario.Postagens),
+	}
+
+	if admin.Situacao != SituacaoAtivo {
+		admin.MotivoSuspensao = usuario.MotivoSuspensao
+		admin.SuspensoAte = usuario.SuspensoAte
+	}
+
+	return admin
+}
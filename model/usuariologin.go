@@ -4,8 +4,12 @@ type UsuarioLogin struct {
 	ID        uint       `json:"id"`
 	Nome      string     `json:"nome"`
 	Usuario   string     `json:"usuario"`
-	Senha     string     `json:"senha"`
+	Senha     string     `json:"senha,omitempty"`
 	Foto      string     `json:"foto"`
 	Token     string     `json:"token"`
+	// Nome do dispositivo mostrado na lista de Sessoes; sem ele, é deduzido do User-Agent
+	Dispositivo string   `json:"dispositivo,omitempty" example:"Notebook do trabalho"`
+	// Indica que o Usuario precisa trocar a senha antes de usar a API
+	RedefinirSenha bool  `json:"redefinir_senha,omitempty"`
 }
 
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// Webhook é um endereço externo avisado quando o conteúdo do blog muda
+type Webhook struct {
+	ID                 uint       `gorm:"primary_key, AUTO_INCREMENT" json:"id"`
+	URL                string     `gorm:"size:500;not null" json:"url" validate:"required,url,max=500" example:"https://exemplo.com/webhooks/blog"`
+	Eventos            []string   `gorm:"serializer:json;type:text;not null" json:"eventos" validate:"required,min=1,dive,required" example:"postagem.criada,tema.*"`
+	Segredo            string     `gorm:"size:100;not null" json:"segredo" validate:"omitempty,min=16,max=100"`
+	Ativo              bool       `gorm:"not null;default:true" json:"ativo"`
+	FalhasConsecutivas int        `gorm:"not null;default:0" json:"falhas_consecutivas"`
+	DesativadoEm       *time.Time `json:"desativado_em,omitempty"`
+	CreatedAt          time.Time  `json:"criado_em"`
+	UpdatedAt          time.Time  `json:"atualizado_em"`
+}
+
+func (Webhook) TableName() string {
+	return "tb_webhooks"
+}
+
+// Situações de uma EntregaWebhook
+const (
+	EntregaPendente = "pendente"
+	EntregaSucesso  = "sucesso"
+	EntregaFalha    = "falha"
+)
+
+// EntregaWebhook registra cada evento enviado a um Webhook e as tentativas de entrega
+type EntregaWebhook struct {
+	ID               uint       `gorm:"primary_key, AUTO_INCREMENT" json:"id"`
+	WebhookID        uint       `gorm:"column:webhook_id;not null;index" json:"webhook_id"`
+	Webhook          Webhook    `gorm:"ForeignKey:WebhookID;constraint:OnDelete:CASCADE;" json:"-"`
+	Evento           string     `gorm:"size:50;not null" json:"evento" example:"postagem.criada"`
+	Payload          string     `gorm:"type:text;not null" json:"payload"`
+	Status           string     `gorm:"size:20;not null;index:idx_entregas_pendentes" json:"status" example:"sucesso"`
+	Tentativas       int        `gorm:"not null;default:0" json:"tentativas"`
+	StatusHTTP       int        `json:"status_http,omitempty"`
+	Resposta         string     `gorm:"size:1000" json:"resposta,omitempty"`
+	Erro             string     `gorm:"size:500" json:"erro,omitempty"`
+	ProximaTentativa *time.Time `gorm:"index:idx_entregas_pendentes" json:"proxima_tentativa,omitempty"`
+	CreatedAt        time.Time  `json:"criado_em"`
+	UpdatedAt        time.Time  `json:"atualizado_em"`
+}
+
+func (EntregaWebhook) TableName() string {
+	return "tb_webhooks_entregas"
+}
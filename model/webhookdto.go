@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// WebhookRequisicao é o corpo da criação e da atualização de um Webhook
+type WebhookRequisicao struct {
+	ID      uint     `json:"id" example:"1"`
+	URL     string   `json:"url" validate:"required,url,max=500" example:"https://exemplo.com/webhooks/blog"`
+	Eventos []string `json:"eventos" validate:"required,min=1,dive,required" example:"postagem.criada,tema.*"`
+	Segredo string   `json:"segredo" validate:"omitempty,min=16,max=100"`
+	Ativo   bool     `json:"ativo"`
+}
+
+// WebhookResposta é o Webhook retornado pela API, sem o segredo de assinatura
+type WebhookResposta struct {
+	ID                 uint       `json:"id"`
+	URL                string     `json:"url" example:"https://exemplo.com/webhooks/blog"`
+	Eventos            []string   `json:"eventos" example:"postagem.criada,tema.*"`
+	Ativo              bool       `json:"ativo"`
+	FalhasConsecutivas int        `json:"falhas_consecutivas"`
+	DesativadoEm       *time.Time `json:"desativado_em,omitempty"`
+	CreatedAt          time.Time  `json:"criado_em"`
+	UpdatedAt          time.Time  `json:"atualizado_em"`
+}
+
+// WebhookCriado é a resposta da criação do Webhook, a única que mostra o segredo de assinatura
+type WebhookCriado struct {
+	WebhookResposta
+	Segredo string `json:"segredo"`
+}
+
+// Modelo cria o Webhook com os dados da requisição
+func (requisicao WebhookRequisicao) Modelo() Webhook {
+	return Webhook{
+		ID:      requisicao.ID,
+		URL:     requisicao.URL,
+		Eventos: requisicao.Eventos,
+		Segredo: requisicao.Segredo,
+		Ativo:   requisicao.Ativo,
+	}
+}
+
+// Resposta converte o Webhook para o formato enviado aos clientes
+func (webhook Webhook) Resposta() WebhookResposta {
+	return WebhookResposta{
+		ID:                 webhook.ID,
+		URL:                webhook.URL,
+		Eventos:            webhook.Eventos,
+		Ativo:              webhook.Ativo,
+		FalhasConsecutivas: webhook.FalhasConsecutivas,
+		DesativadoEm:       webhook.DesativadoEm,
+		CreatedAt:          webhook.CreatedAt,
+		UpdatedAt:          webhook.UpdatedAt,
+	}
+}
+
+// Criado converte o Webhook recém-criado para a resposta que inclui o segredo
+func (webhook Webhook) Criado() WebhookCriado {
+	return WebhookCriado{WebhookResposta: webhook.Resposta(), Segredo: webhook.Segredo}
+}
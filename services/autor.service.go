@@ -0,0 +1,57 @@
+package services
+
+import (
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// PerfilAutor é a página pública de um autor: o perfil, os Temas em que ele escreve
+// e uma página das Postagens publicadas por ele
+type PerfilAutor struct {
+	Autor          model.UsuarioPublico     `json:"autor"`
+	TotalPostagens int64                    `json:"total_postagens"`
+	Temas          []model.TemaResumo       `json:"temas"`
+	Pagina         int                      `json:"pagina"`
+	Tamanho        int                      `json:"tamanho"`
+	Postagens      []model.PostagemResposta `json:"postagens"`
+}
+
+// PerfilDoAutor monta a página pública do autor com o slug informado. Rascunhos não são
+// considerados em nenhuma das contagens, e contas banidas ou pendentes não têm página.
+func PerfilDoAutor(db *gorm.DB, slug string, pagina int, tamanho int) (PerfilAutor, bool) {
+
+	var autor model.Usuario
+	if db.Scopes(UsuariosVisiveis).Where("slug = ?", slug).Find(&autor); autor.ID == 0 {
+		return PerfilAutor{}, false
+	}
+
+	postagens := model.Postagem{}.TableName()
+	temas := model.Tema{}.TableName()
+
+	perfil := PerfilAutor{Autor: autor.Publico(), Pagina: pagina, Tamanho: tamanho, Temas: []model.TemaResumo{}}
+
+	publicadas := db.Model(&model.Postagem{}).
+		Where(postagens+".usuario_id = ? AND "+postagens+".status = ?", autor.ID, model.StatusPublicado).
+		Session(&gorm.Session{})
+
+	publicadas.Count(&perfil.TotalPostagens)
+
+	publicadas.Select(temas + ".id, " + temas + ".descricao, " + temas + ".slug").
+		Joins("JOIN " + temas + " ON " + temas + ".id = " + postagens + ".tema_id AND " + temas + ".deleted_at IS NULL").
+		Group(temas + ".id, " + temas + ".descricao, " + temas + ".slug").
+		Order(temas + ".descricao").
+		Scan(&perfil.Temas)
+
+	var lista []model.Postagem
+	publicadas.Joins("Tema").
+		Order("data DESC, " + postagens + ".id DESC").
+		Offset((pagina - 1) * tamanho).
+		Limit(tamanho).
+		Find(&lista)
+
+	PreencherBreadcrumbs(db, lista)
+	perfil.Postagens = model.PostagensResposta(lista)
+
+	return perfil, true
+}
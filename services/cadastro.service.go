@@ -0,0 +1,213 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"blogpessoal/cache"
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// Modos de cadastro de novos Usuarios
+const (
+	// CadastroAberto permite que qualquer pessoa crie uma conta
+	CadastroAberto = "aberto"
+	// CadastroConvite exige o código de um Convite válido
+	CadastroConvite = "convite"
+	// CadastroAprovacao deixa as novas contas pendentes até um administrador aprová-las.
+	// Um Convite válido dispensa a aprovação.
+	CadastroAprovacao = "aprovacao"
+	// CadastroFechado não aceita novos cadastros
+	CadastroFechado = "fechado"
+)
+
+// ModosCadastro lista os modos de cadastro aceitos na configuração
+var ModosCadastro = []string{CadastroAberto, CadastroConvite, CadastroAprovacao, CadastroFechado}
+
+// ModoCadastro é o modo de cadastro em vigor
+var ModoCadastro = CadastroAberto
+
+// Erros do cadastro de Usuarios
+var (
+	ErrCadastroFechado     = errors.New("O cadastro de novos Usuarios está fechado!")
+	ErrConviteObrigatorio  = errors.New("O cadastro é apenas por convite! Informe o código do convite.")
+	ErrConviteInvalido     = errors.New("Convite inválido, expirado ou esgotado!")
+	ErrCadastroNaoPendente = errors.New("O cadastro deste Usuario não está aguardando aprovação!")
+)
+
+// ModoCadastroValido informa se o modo existe
+func ModoCadastroValido(modo string) bool {
+	for _, valido := range ModosCadastro {
+		if modo == valido {
+			return true
+		}
+	}
+	return false
+}
+
+// CadastrarUsuario grava o novo Usuario conforme o modo de cadastro: consome um uso do Convite
+// informado e, no modo com aprovação e sem Convite, deixa a conta pendente
+func CadastrarUsuario(db *gorm.DB, usuario *model.Usuario, codigoConvite string) error {
+
+	codigoConvite = normalizarCodigoConvite(codigoConvite)
+
+	switch {
+	case ModoCadastro == CadastroFechado:
+		return ErrCadastroFechado
+	case ModoCadastro == CadastroConvite && codigoConvite == "":
+		return ErrConviteObrigatorio
+	}
+
+	usuario.Situacao = model.SituacaoAtivo
+	if ModoCadastro == CadastroAprovacao && codigoConvite == "" {
+		usuario.Situacao = model.SituacaoPendente
+	}
+
+	return cache.Transaction(db, func(tx *gorm.DB) error {
+
+		// No modo aberto o código é ignorado, para um Convite antigo não impedir o cadastro
+		if codigoConvite != "" && ModoCadastro != CadastroAberto {
+			convite, err := usarConvite(tx, codigoConvite)
+			if err != nil {
+				return err
+			}
+			usuario.ConviteID = &convite.ID
+		}
+
+		return tx.Omit("Postagens").Create(usuario).Error
+	})
+}
+
+// usarConvite consome um uso do Convite, se ele ainda estiver disponível
+func usarConvite(tx *gorm.DB, codigo string) (model.Convite, error) {
+
+	var convite model.Convite
+	tx.Where("codigo = ?", codigo).Find(&convite)
+	if convite.ID == 0 {
+		return convite, ErrConviteInvalido
+	}
+
+	// A condição no UPDATE garante que cadastros simultâneos não ultrapassem o limite de usos
+	result := tx.Model(&model.Convite{}).
+		Where("id = ? AND usos < limite_usos AND (expira_em IS NULL OR expira_em > ?)", convite.ID, time.Now()).
+		UpdateColumn("usos", gorm.Expr("usos + 1"))
+	if result.Error != nil {
+		return convite, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return convite, ErrConviteInvalido
+	}
+
+	return convite, nil
+}
+
+// CriarConvite gera um Convite com código aleatório
+func CriarConvite(db *gorm.DB, convite model.Convite) (model.Convite, error) {
+
+	codigo := make([]byte, 6)
+	if _, err := rand.Read(codigo); err != nil {
+		return convite, err
+	}
+	convite.Codigo = strings.ToUpper(hex.EncodeToString(codigo))
+
+	if convite.LimiteUsos <= 0 {
+		convite.LimiteUsos = 1
+	}
+
+	err := db.Create(&convite).Error
+	convite.Disponivel = convite.DisponivelEm(time.Now())
+	return convite, err
+}
+
+// ListarConvites lista os Convites, dos mais recentes aos mais antigos
+func ListarConvites(db *gorm.DB) []model.Convite {
+
+	convites := []model.Convite{}
+	db.Order("created_at DESC, id DESC").Find(&convites)
+
+	agora := time.Now()
+	for i := range convites {
+		convites[i].Disponivel = convites[i].DisponivelEm(agora)
+	}
+
+	return convites
+}
+
+// RemoverConvite apaga o Convite; as contas criadas com ele não são afetadas
+func RemoverConvite(db *gorm.DB, id uint) error {
+
+	result := db.Delete(&model.Convite{}, id)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return result.Error
+}
+
+// ListarCadastrosPendentes lista os Usuarios que aguardam aprovação, dos mais antigos aos mais recentes
+func ListarCadastrosPendentes(db *gorm.DB) []model.UsuarioAdmin {
+
+	var usuarios []model.Usuario
+	db.Where("situacao = ?", model.SituacaoPendente).Order("id").Find(&usuarios)
+
+	pendentes := make([]model.UsuarioAdmin, len(usuarios))
+	for i, usuario := range usuarios {
+		pendentes[i] = usuario.Admin()
+	}
+
+	return pendentes
+}
+
+// AprovarCadastro ativa a conta de um Usuario pendente
+func AprovarCadastro(db *gorm.DB, usuarioID uint) error {
+
+	result := db.Model(&model.Usuario{}).Where("id = ? AND situacao = ?", usuarioID, model.SituacaoPendente).UpdateColumn("situacao", model.SituacaoAtivo)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrCadastroNaoPendente
+	}
+
+	return result.Error
+}
+
+// RejeitarCadastro apaga definitivamente a conta de um Usuario pendente, que ainda não tem nenhum conteúdo
+func RejeitarCadastro(db *gorm.DB, usuarioID uint) error {
+
+	result := db.Unscoped().Where("id = ? AND situacao = ?", usuarioID, model.SituacaoPendente).Delete(&model.Usuario{})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrCadastroNaoPendente
+	}
+
+	return result.Error
+}
+
+// NotificarCadastroPendente avisa os administradores que um novo cadastro aguarda aprovação
+func NotificarCadastroPendente(db *gorm.DB, usuario model.Usuario) {
+
+	var administradores []uint
+	db.Model(&model.Usuario{}).Where("perfil = ? AND situacao = ?", model.PerfilAdmin, model.SituacaoAtivo).Pluck("id", &administradores)
+
+	notificacoes := make([]model.Notificacao, len(administradores))
+	for i, administrador := range administradores {
+		notificacoes[i] = model.Notificacao{
+			UsuarioID: administrador,
+			Tipo:      model.NotificacaoCadastroPendente,
+			Mensagem:  fmt.Sprintf("%s (%s) aguarda a aprovação do cadastro", usuario.Nome, usuario.Usuario),
+			AutorID:   &usuario.ID,
+		}
+	}
+
+	if err := Notificar(db, notificacoes); err != nil {
+		log.Printf("Erro ao notificar o cadastro pendente: %s", err)
+	}
+}
+
+func normalizarCodigoConvite(codigo string) string {
+	return strings.ToUpper(strings.TrimSpace(codigo))
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"blogpessoal/cache"
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// Políticas para as Postagens publicadas de uma conta excluída
+const (
+	// ExclusaoApagar apaga definitivamente as Postagens junto com a conta
+	ExclusaoApagar = "apagar"
+	// ExclusaoAnonimizar mantém as Postagens publicadas, sem nenhum dado do autor
+	ExclusaoAnonimizar = "anonimizar"
+)
+
+// CarenciaExclusaoConta é o prazo entre o pedido de exclusão da conta e a exclusão,
+// durante o qual o Usuario pode cancelar o pedido. Zero exclui na próxima verificação.
+var CarenciaExclusaoConta = 15 * 24 * time.Hour
+
+// PoliticaExclusaoConta decide o que acontece com as Postagens publicadas de uma conta excluída
+var PoliticaExclusaoConta = ExclusaoAnonimizar
+
+// Erros do pedido de exclusão da conta
+var (
+	ErrExclusaoJaAgendada  = errors.New("A exclusão da conta já foi agendada!")
+	ErrExclusaoNaoAgendada = errors.New("Não há exclusão da conta agendada!")
+)
+
+// AgendarExclusaoConta marca a conta do Usuario para ser excluída ao fim da carência
+// e retorna a data da exclusão
+func AgendarExclusaoConta(db *gorm.DB, usuarioID uint, agora time.Time) (time.Time, error) {
+
+	exclusao := agora.Add(CarenciaExclusaoConta)
+
+	result := db.Model(&model.Usuario{}).Where("id = ? AND exclusao_agendada_em IS NULL", usuarioID).UpdateColumn("exclusao_agendada_em", exclusao)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return exclusao, ErrExclusaoJaAgendada
+	}
+
+	return exclusao, result.Error
+}
+
+// CancelarExclusaoConta desfaz o pedido de exclusão da conta do Usuario
+func CancelarExclusaoConta(db *gorm.DB, usuarioID uint) error {
+
+	result := db.Model(&model.Usuario{}).Where("id = ? AND exclusao_agendada_em IS NOT NULL", usuarioID).UpdateColumn("exclusao_agendada_em", nil)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrExclusaoNaoAgendada
+	}
+
+	return result.Error
+}
+
+// ExcluirConta apaga os dados pessoais do Usuario: seguidores, Temas seguidos, Notificacoes,
+// a assinatura do resumo, as Sessoes, os rascunhos e as Postagens na lixeira. As Postagens publicadas são
+// apagadas ou ficam sem autor, conforme a política. Nos dois casos os tokens do Usuario deixam de valer.
+// Cada Postagem apagada que não estava na lixeira dispara postagem.removida depois da transação.
+func ExcluirConta(db *gorm.DB, usuarioID uint, politica string) error {
+
+	var removidas []model.Postagem
+	err := cache.Transaction(db, func(tx *gorm.DB) error {
+
+		if err := tx.Where("usuario_id = ? OR seguidor_id = ?", usuarioID, usuarioID).Delete(&model.SeguidorUsuario{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("usuario_id = ?", usuarioID).Delete(&model.SeguidorTema{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("usuario_id = ? OR autor_id = ?", usuarioID, usuarioID).Delete(&model.Notificacao{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("usuario_id = ?", usuarioID).Delete(&model.AssinaturaResumo{}).Error; err != nil {
+			return err
+		}
+		if err := EncerrarSessoes(tx, usuarioID); err != nil {
+			return err
+		}
+
+		postagens := tx.Unscoped().Where("usuario_id = ?", usuarioID)
+		if politica == ExclusaoAnonimizar {
+			postagens = postagens.Where("status <> ? OR deleted_at IS NOT NULL", model.StatusPublicado)
+		}
+		postagens = postagens.Session(&gorm.Session{})
+
+		// As que já estavam na lixeira dispararam o evento quando foram para lá
+		if err := postagens.Where("deleted_at IS NULL").Find(&removidas).Error; err != nil {
+			return err
+		}
+		if err := postagens.Delete(&model.Postagem{}).Error; err != nil {
+			return err
+		}
+
+		if politica != ExclusaoAnonimizar {
+			return tx.Unscoped().Delete(&model.Usuario{}, usuarioID).Error
+		}
+
+		// O registro continua existindo, na lixeira, apenas para as Postagens publicadas não perderem o autor
+		return tx.Unscoped().Model(&model.Usuario{}).Where("id = ?", usuarioID).UpdateColumns(map[string]interface{}{
+			"nome":                 "Usuário removido",
+			"usuario":              fmt.Sprintf("removido-%d@anonimo.invalid", usuarioID),
+			"senha":                SenhaBloqueada,
+			"foto":                 "",
+			"slug":                 fmt.Sprintf("removido-%d", usuarioID),
+			"sobre":                "",
+			"site":                 "",
+			"links":                nil,
+			"perfil":               model.PerfilUsuario,
+			"situacao":             model.SituacaoBanido,
+			"motivo_suspensao":     "",
+			"suspenso_ate":         nil,
+			"redefinir_senha":      false,
+			"ultimo_login":         nil,
+			"exclusao_agendada_em": nil,
+			"versao_token":         gorm.Expr("versao_token + 1"),
+			"deleted_at":           time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, postagem := range removidas {
+		DispararWebhookPostagem(db, EventoPostagemRemovida, postagem)
+	}
+	return nil
+}
+
+// ExcluirContasVencidas exclui as contas cuja carência terminou antes de agora
+func ExcluirContasVencidas(db *gorm.DB, agora time.Time) (int, error) {
+
+	var usuarios []model.Usuario
+	if err := db.Where("exclusao_agendada_em <= ?", agora).Find(&usuarios).Error; err != nil {
+		return 0, err
+	}
+
+	excluidas := 0
+	for _, usuario := range usuarios {
+		if err := ExcluirConta(db, usuario.ID, PoliticaExclusaoConta); err != nil {
+			return excluidas, err
+		}
+		DispararWebhook(db, EventoUsuarioRemovido, map[string]interface{}{"id": usuario.ID})
+		excluidas++
+	}
+
+	return excluidas, nil
+}
+
+// IniciarExclusaoContas exclui periodicamente, em segundo plano, as contas cuja carência terminou
+func IniciarExclusaoContas(db *gorm.DB, intervalo time.Duration) {
+
+	if intervalo <= 0 {
+		return
+	}
+
+	go func() {
+		for {
+			excluidas, err := ExcluirContasVencidas(db, time.Now())
+			if err != nil {
+				log.Printf("Erro ao excluir as contas: %s", err)
+			} else if excluidas > 0 {
+				log.Printf("%d contas excluídas a pedido dos usuarios", excluidas)
+			}
+			time.Sleep(intervalo)
+		}
+	}()
+}
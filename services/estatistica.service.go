@@ -0,0 +1,97 @@
+package services
+
+import (
+	"time"
+
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// EstatisticaTema reúne os números de um Tema calculados com SQL agregado
+type EstatisticaTema struct {
+	ID                uint         `json:"id"`
+	Descricao         string       `json:"descricao"`
+	Slug              string       `json:"slug"`
+	TotalPostagens    int64        `json:"total_postagens"`
+	UltimaPostagem    *time.Time   `json:"ultima_postagem"`
+	TotalAutores      int64        `json:"total_autores"`
+	PrincipaisAutores []AutorTema  `json:"principais_autores"`
+	PostagensPorMes   []PontoSerie `json:"postagens_por_mes"`
+}
+
+type AutorTema struct {
+	UsuarioID      uint   `json:"usuario_id"`
+	Nome           string `json:"nome"`
+	TotalPostagens int64  `json:"total_postagens"`
+}
+
+type PontoSerie struct {
+	Mes   string `json:"mes" example:"2023-07"`
+	Total int64  `json:"total"`
+}
+
+// EstatisticasTemas calcula, por Tema, o total de Postagens, a data da última, o número de
+// autores distintos, os maiores autores e a série mensal dos últimos meses
+func EstatisticasTemas(db *gorm.DB, maxAutores int, meses int) []EstatisticaTema {
+
+	postagens := model.Postagem{}.TableName()
+	temas := model.Tema{}.TableName()
+	usuarios := model.Usuario{}.TableName()
+
+	var estatisticas []EstatisticaTema
+	db.Table(temas + " AS t").
+		Select("t.id, t.descricao, t.slug, COUNT(p.id) AS total_postagens, MAX(p.data) AS ultima_postagem, COUNT(DISTINCT p.usuario_id) AS total_autores").
+		Joins("LEFT JOIN " + postagens + " AS p ON p.tema_id = t.id AND p.deleted_at IS NULL").
+		Where("t.deleted_at IS NULL").
+		Group("t.id, t.descricao, t.slug").
+		Order("total_postagens DESC, t.descricao").
+		Scan(&estatisticas)
+
+	var autores []struct {
+		TemaID uint
+		AutorTema
+	}
+	db.Table(postagens + " AS p").
+		Select("p.tema_id, u.id AS usuario_id, u.nome, COUNT(*) AS total_postagens").
+		Joins("JOIN " + usuarios + " AS u ON u.id = p.usuario_id").
+		Where("p.deleted_at IS NULL").
+		Group("p.tema_id, u.id, u.nome").
+		Order("p.tema_id, total_postagens DESC, u.nome").
+		Scan(&autores)
+
+	var serie []struct {
+		TemaID uint
+		PontoSerie
+	}
+	inicio := time.Now().AddDate(0, -meses+1, 0)
+	inicio = time.Date(inicio.Year(), inicio.Month(), 1, 0, 0, 0, 0, time.Local)
+	db.Table(postagens).
+		Select("tema_id, DATE_FORMAT(data, '%Y-%m') AS mes, COUNT(*) AS total").
+		Where("data >= ? AND deleted_at IS NULL", inicio).
+		Group("tema_id, mes").
+		Order("tema_id, mes").
+		Scan(&serie)
+
+	indice := make(map[uint]int, len(estatisticas))
+	for i := range estatisticas {
+		estatisticas[i].PrincipaisAutores = []AutorTema{}
+		estatisticas[i].PostagensPorMes = []PontoSerie{}
+		indice[estatisticas[i].ID] = i
+	}
+
+	for _, autor := range autores {
+		i, ok := indice[autor.TemaID]
+		if ok && len(estatisticas[i].PrincipaisAutores) < maxAutores {
+			estatisticas[i].PrincipaisAutores = append(estatisticas[i].PrincipaisAutores, autor.AutorTema)
+		}
+	}
+
+	for _, ponto := range serie {
+		if i, ok := indice[ponto.TemaID]; ok {
+			estatisticas[i].PostagensPorMes = append(estatisticas[i].PostagensPorMes, ponto.PontoSerie)
+		}
+	}
+
+	return estatisticas
+}
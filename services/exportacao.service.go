@@ -0,0 +1,131 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"regexp"
+	"time"
+
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// PostagemExportada é uma Postagem do Usuario no arquivo de exportação, com a data de remoção
+// das que estão na lixeira
+type PostagemExportada struct {
+	model.PostagemResposta
+	RemovidaEm *time.Time `json:"removida_em,omitempty"`
+}
+
+// MidiaExportada é um arquivo de mídia referenciado pelo Usuario. As mídias ficam em
+// servidores externos ao blog, por isso o arquivo de exportação traz apenas os endereços.
+type MidiaExportada struct {
+	URL        string `json:"url"`
+	Origem     string `json:"origem"`
+	PostagemID uint   `json:"postagem_id,omitempty"`
+}
+
+// Imagens no Markdown: ![descrição](endereço "título")
+var imagemMarkdown = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?([^)\s>]+)>?`)
+
+const leiaMeExportacao = `Dados do Usuario exportados do Blog Pessoal
+
+perfil.json        dados da conta e do perfil público
+postagens.json     Postagens publicadas, rascunhos e Postagens na lixeira
+postagens/*.md     texto de cada Postagem em Markdown, com front matter
+seguidores.json    quem segue o Usuario e quem ele segue, Usuarios e Temas
+notificacoes.json  todas as Notificacoes recebidas
+resumo.json        preferência do resumo de novas Postagens por e-mail
+atividade.json     números da atividade no blog
+midia.json         endereços das imagens usadas na foto do perfil e nas Postagens
+
+O blog não tem comentários. As imagens ficam em servidores externos ao blog e
+não são copiadas para este arquivo; use os endereços de midia.json para baixá-las.
+`
+
+// ExportarDadosUsuario grava em w um arquivo zip com todos os dados do Usuario
+func ExportarDadosUsuario(db *gorm.DB, usuario model.Usuario, w io.Writer) error {
+
+	arquivo := zip.NewWriter(w)
+
+	var postagens []model.Postagem
+	db.Unscoped().Joins("Tema").Where(model.Postagem{}.TableName()+".usuario_id = ?", usuario.ID).Order("data DESC").Find(&postagens)
+
+	exportadas := make([]PostagemExportada, len(postagens))
+	midias := []MidiaExportada{}
+	if usuario.Foto != "" {
+		midias = append(midias, MidiaExportada{URL: usuario.Foto, Origem: "foto"})
+	}
+
+	for i, postagem := range postagens {
+		exportadas[i] = PostagemExportada{PostagemResposta: postagem.Resposta()}
+		if postagem.DeletedAt.Valid {
+			removida := postagem.DeletedAt.Time
+			exportadas[i].RemovidaEm = &removida
+		}
+
+		for _, imagem := range imagemMarkdown.FindAllStringSubmatch(postagem.Texto, -1) {
+			midias = append(midias, MidiaExportada{URL: imagem[1], Origem: "postagem", PostagemID: postagem.ID})
+		}
+
+		postagem.Usuario = usuario
+		conteudo, err := gerarMarkdown(postagem)
+		if err != nil {
+			return err
+		}
+		if err := gravarNoZip(arquivo, "postagens/"+postagem.Slug+".md", conteudo); err != nil {
+			return err
+		}
+	}
+
+	notificacoes := []model.Notificacao{}
+	db.Where("usuario_id = ?", usuario.ID).Order("created_at DESC, id DESC").Find(&notificacoes)
+
+	var assinatura model.AssinaturaResumo
+	db.Where("usuario_id = ?", usuario.ID).Find(&assinatura)
+
+	arquivosJSON := []struct {
+		nome  string
+		dados interface{}
+	}{
+		{"perfil.json", usuario.Proprio()},
+		{"postagens.json", exportadas},
+		{"seguidores.json", map[string]interface{}{
+			"seguidores": SeguidoresUsuario(db, usuario.ID),
+			"seguindo":   SeguidosPorUsuario(db, usuario.ID),
+		}},
+		{"notificacoes.json", notificacoes},
+		{"resumo.json", assinatura},
+		{"atividade.json", AtividadeDoUsuario(db, usuario)},
+		{"midia.json", midias},
+	}
+
+	for _, arquivoJSON := range arquivosJSON {
+		conteudo, err := json.MarshalIndent(arquivoJSON.dados, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := gravarNoZip(arquivo, arquivoJSON.nome, conteudo); err != nil {
+			return err
+		}
+	}
+
+	if err := gravarNoZip(arquivo, "LEIA-ME.txt", []byte(leiaMeExportacao)); err != nil {
+		return err
+	}
+
+	return arquivo.Close()
+}
+
+func gravarNoZip(arquivo *zip.Writer, nome string, conteudo []byte) error {
+
+	destino, err := arquivo.Create(nome)
+	if err != nil {
+		return err
+	}
+
+	_, err = destino.Write(conteudo)
+	return err
+}
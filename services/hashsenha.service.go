@@ -0,0 +1,158 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Algoritmos de hash de senha
+const (
+	HashBcrypt   = "bcrypt"
+	HashArgon2id = "argon2id"
+)
+
+// ConfigHashSenha escolhe o algoritmo e os parâmetros dos hashes das novas senhas.
+// O algoritmo e os parâmetros ficam gravados no próprio hash, no formato PHC
+// ($argon2id$v=19$m=...,t=...,p=...$sal$hash) ou no formato do bcrypt ($2a$custo$...),
+// então hashes antigos continuam sendo conferidos depois de uma mudança na configuração.
+type ConfigHashSenha struct {
+	Algoritmo   string
+	BcryptCusto int
+	// Memória do Argon2id em KiB
+	Argon2Memoria     uint32
+	Argon2Iteracoes   uint32
+	Argon2Paralelismo uint8
+}
+
+// HashSenhas é a configuração usada ao gerar os hashes. O padrão segue a recomendação da OWASP para o Argon2id.
+var HashSenhas = ConfigHashSenha{
+	Algoritmo:         HashArgon2id,
+	BcryptCusto:       bcrypt.DefaultCost,
+	Argon2Memoria:     19 * 1024,
+	Argon2Iteracoes:   2,
+	Argon2Paralelismo: 1,
+}
+
+const (
+	tamanhoSalArgon2  = 16
+	tamanhoHashArgon2 = 32
+)
+
+// ErrHashSenhaInvalido indica um hash gravado que não está em nenhum formato conhecido
+var ErrHashSenhaInvalido = errors.New("hash de senha em formato desconhecido")
+
+// Validar confere se o algoritmo e os parâmetros podem ser usados
+func (config ConfigHashSenha) Validar() error {
+
+	switch config.Algoritmo {
+	case HashBcrypt:
+		if config.BcryptCusto < bcrypt.MinCost || config.BcryptCusto > bcrypt.MaxCost {
+			return fmt.Errorf("custo do bcrypt deve estar entre %d e %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case HashArgon2id:
+		if config.Argon2Memoria < 8*uint32(config.Argon2Paralelismo) || config.Argon2Iteracoes < 1 || config.Argon2Paralelismo < 1 {
+			return errors.New("parâmetros do argon2id inválidos")
+		}
+	default:
+		return fmt.Errorf("algoritmo de hash de senha desconhecido: %q (use %q ou %q)", config.Algoritmo, HashBcrypt, HashArgon2id)
+	}
+
+	return nil
+}
+
+// GerarHashSenha gera o hash da senha com o algoritmo e os parâmetros configurados
+func GerarHashSenha(senha string) (string, error) {
+
+	if HashSenhas.Algoritmo == HashBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(senha), HashSenhas.BcryptCusto)
+		return string(hash), err
+	}
+
+	sal := make([]byte, tamanhoSalArgon2)
+	if _, err := rand.Read(sal); err != nil {
+		return "", err
+	}
+
+	hash := argon2.IDKey([]byte(senha), sal, HashSenhas.Argon2Iteracoes, HashSenhas.Argon2Memoria, HashSenhas.Argon2Paralelismo, tamanhoHashArgon2)
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", HashArgon2id, argon2.Version,
+		HashSenhas.Argon2Memoria, HashSenhas.Argon2Iteracoes, HashSenhas.Argon2Paralelismo,
+		base64.RawStdEncoding.EncodeToString(sal), base64.RawStdEncoding.EncodeToString(hash)), nil
+}
+
+// ConferirSenha compara a senha com o hash gravado e informa se o hash deve ser
+// gerado de novo por usar um algoritmo ou parâmetros diferentes dos configurados
+func ConferirSenha(senha string, hashGravado string) (confere bool, desatualizado bool) {
+
+	if strings.HasPrefix(hashGravado, "$"+HashArgon2id+"$") {
+		return conferirArgon2id(senha, hashGravado)
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hashGravado), []byte(senha)) != nil {
+		return false, false
+	}
+
+	custo, _ := bcrypt.Cost([]byte(hashGravado))
+	return true, HashSenhas.Algoritmo != HashBcrypt || custo != HashSenhas.BcryptCusto
+}
+
+func conferirArgon2id(senha string, hashGravado string) (bool, bool) {
+
+	parametros, sal, hash, err := lerHashArgon2id(hashGravado)
+	if err != nil {
+		return false, false
+	}
+
+	calculado := argon2.IDKey([]byte(senha), sal, parametros.Argon2Iteracoes, parametros.Argon2Memoria, parametros.Argon2Paralelismo, uint32(len(hash)))
+	if subtle.ConstantTimeCompare(calculado, hash) != 1 {
+		return false, false
+	}
+
+	desatualizado := HashSenhas.Algoritmo != HashArgon2id ||
+		parametros.Argon2Memoria != HashSenhas.Argon2Memoria ||
+		parametros.Argon2Iteracoes != HashSenhas.Argon2Iteracoes ||
+		parametros.Argon2Paralelismo != HashSenhas.Argon2Paralelismo ||
+		len(hash) != tamanhoHashArgon2
+
+	return true, desatualizado
+}
+
+// lerHashArgon2id separa os parâmetros, o sal e o hash de uma string no formato PHC
+func lerHashArgon2id(hashGravado string) (ConfigHashSenha, []byte, []byte, error) {
+
+	parametros := ConfigHashSenha{Algoritmo: HashArgon2id}
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", sal, hash
+	partes := strings.Split(hashGravado, "$")
+	if len(partes) != 6 {
+		return parametros, nil, nil, ErrHashSenhaInvalido
+	}
+
+	var versao int
+	if _, err := fmt.Sscanf(partes[2], "v=%d", &versao); err != nil || versao != argon2.Version {
+		return parametros, nil, nil, ErrHashSenhaInvalido
+	}
+
+	if _, err := fmt.Sscanf(partes[3], "m=%d,t=%d,p=%d", &parametros.Argon2Memoria, &parametros.Argon2Iteracoes, &parametros.Argon2Paralelismo); err != nil {
+		return parametros, nil, nil, ErrHashSenhaInvalido
+	}
+
+	sal, err := base64.RawStdEncoding.DecodeString(partes[4])
+	if err != nil {
+		return parametros, nil, nil, ErrHashSenhaInvalido
+	}
+
+	hash, err := base64.RawStdEncoding.DecodeString(partes[5])
+	if err != nil || len(hash) == 0 {
+		return parametros, nil, nil, ErrHashSenhaInvalido
+	}
+
+	return parametros, sal, hash, nil
+}
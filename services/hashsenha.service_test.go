@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Parâmetros baixos, só para os testes rodarem rápido
+var (
+	configArgon2Teste = ConfigHashSenha{Algoritmo: HashArgon2id, BcryptCusto: bcrypt.MinCost, Argon2Memoria: 64, Argon2Iteracoes: 1, Argon2Paralelismo: 1}
+	configBcryptTeste = ConfigHashSenha{Algoritmo: HashBcrypt, BcryptCusto: bcrypt.MinCost, Argon2Memoria: 64, Argon2Iteracoes: 1, Argon2Paralelismo: 1}
+)
+
+func usarHashSenhas(t *testing.T, config ConfigHashSenha) {
+	t.Helper()
+	anterior := HashSenhas
+	HashSenhas = config
+	t.Cleanup(func() { HashSenhas = anterior })
+}
+
+func gerarHashTeste(t *testing.T, config ConfigHashSenha, senha string) string {
+	t.Helper()
+	usarHashSenhas(t, config)
+	hash, err := GerarHashSenha(senha)
+	if err != nil {
+		t.Fatalf("GerarHashSenha: %s", err)
+	}
+	return hash
+}
+
+func TestConferirSenha(t *testing.T) {
+
+	argon2 := gerarHashTeste(t, configArgon2Teste, "senha-correta")
+	bcryptHash := gerarHashTeste(t, configBcryptTeste, "senha-correta")
+
+	maisMemoria := configArgon2Teste
+	maisMemoria.Argon2Memoria = 128
+	maisIteracoes := configArgon2Teste
+	maisIteracoes.Argon2Iteracoes = 2
+	bcryptMaisCaro := configBcryptTeste
+	bcryptMaisCaro.BcryptCusto = bcrypt.MinCost + 1
+
+	casos := []struct {
+		nome          string
+		config        ConfigHashSenha
+		senha         string
+		hash          string
+		confere       bool
+		desatualizado bool
+	}{
+		{"argon2id confere", configArgon2Teste, "senha-correta", argon2, true, false},
+		{"argon2id senha errada", configArgon2Teste, "senha-errada", argon2, false, false},
+		{"argon2id memória alterada", maisMemoria, "senha-correta", argon2, true, true},
+		{"argon2id iterações alteradas", maisIteracoes, "senha-correta", argon2, true, true},
+		{"argon2id com bcrypt configurado", configBcryptTeste, "senha-correta", argon2, true, true},
+		{"argon2id senha errada não é desatualizado", maisMemoria, "senha-errada", argon2, false, false},
+		{"bcrypt confere", configBcryptTeste, "senha-correta", bcryptHash, true, false},
+		{"bcrypt senha errada", configBcryptTeste, "senha-errada", bcryptHash, false, false},
+		{"bcrypt custo alterado", bcryptMaisCaro, "senha-correta", bcryptHash, true, true},
+		{"bcrypt com argon2id configurado", configArgon2Teste, "senha-correta", bcryptHash, true, true},
+		{"senha bloqueada", configArgon2Teste, "", SenhaBloqueada, false, false},
+		{"senha bloqueada com texto", configArgon2Teste, "!", SenhaBloqueada, false, false},
+		{"hash vazio", configArgon2Teste, "", "", false, false},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			usarHashSenhas(t, caso.config)
+
+			confere, desatualizado := ConferirSenha(caso.senha, caso.hash)
+			if confere != caso.confere || desatualizado != caso.desatualizado {
+				t.Errorf("ConferirSenha = (%v, %v), esperado (%v, %v)", confere, desatualizado, caso.confere, caso.desatualizado)
+			}
+		})
+	}
+}
+
+func TestGerarHashSenhaUsaSalAleatorio(t *testing.T) {
+
+	primeiro := gerarHashTeste(t, configArgon2Teste, "mesma-senha")
+	segundo := gerarHashTeste(t, configArgon2Teste, "mesma-senha")
+	if primeiro == segundo {
+		t.Errorf("dois hashes da mesma senha são iguais: %s", primeiro)
+	}
+}
+
+func TestLerHashArgon2id(t *testing.T) {
+
+	parametros, sal, hash, err := lerHashArgon2id("$argon2id$v=19$m=65536,t=3,p=4$c2FsdGVzdGVzYWx0$aGFzaGhhc2hoYXNo")
+	if err != nil {
+		t.Fatalf("lerHashArgon2id: %s", err)
+	}
+	if parametros.Argon2Memoria != 65536 || parametros.Argon2Iteracoes != 3 || parametros.Argon2Paralelismo != 4 {
+		t.Errorf("parâmetros lidos = %+v", parametros)
+	}
+	if string(sal) != "saltestesalt" || string(hash) != "hashhashhash" {
+		t.Errorf("sal = %q, hash = %q", sal, hash)
+	}
+
+	invalidos := []struct {
+		nome string
+		hash string
+	}{
+		{"partes faltando", "$argon2id$v=19$m=64,t=1,p=1$c2Fs"},
+		{"partes sobrando", "$argon2id$v=19$m=64,t=1,p=1$c2Fs$aGFzaA$extra"},
+		{"versão antiga", "$argon2id$v=16$m=64,t=1,p=1$c2Fs$aGFzaA"},
+		{"sem versão", "$argon2id$m=64,t=1,p=1$c2Fs$aGFzaA$"},
+		{"parâmetros ilegíveis", "$argon2id$v=19$memoria=64$c2Fs$aGFzaA"},
+		{"sal fora do base64", "$argon2id$v=19$m=64,t=1,p=1$s@l$aGFzaA"},
+		{"base64 com preenchimento", "$argon2id$v=19$m=64,t=1,p=1$c2Fs$aGFzaA=="},
+		{"hash vazio", "$argon2id$v=19$m=64,t=1,p=1$c2Fs$"},
+	}
+
+	for _, caso := range invalidos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if _, _, _, err := lerHashArgon2id(caso.hash); !errors.Is(err, ErrHashSenhaInvalido) {
+				t.Errorf("erro = %v, esperado ErrHashSenhaInvalido", err)
+			}
+		})
+	}
+}
+
+func TestConfigHashSenhaValidar(t *testing.T) {
+
+	casos := []struct {
+		nome   string
+		config ConfigHashSenha
+		valido bool
+	}{
+		{"padrão", HashSenhas, true},
+		{"bcrypt", configBcryptTeste, true},
+		{"bcrypt custo baixo", ConfigHashSenha{Algoritmo: HashBcrypt, BcryptCusto: bcrypt.MinCost - 1}, false},
+		{"bcrypt custo alto", ConfigHashSenha{Algoritmo: HashBcrypt, BcryptCusto: bcrypt.MaxCost + 1}, false},
+		{"argon2id sem iterações", ConfigHashSenha{Algoritmo: HashArgon2id, Argon2Memoria: 64, Argon2Paralelismo: 1}, false},
+		{"argon2id sem paralelismo", ConfigHashSenha{Algoritmo: HashArgon2id, Argon2Memoria: 64, Argon2Iteracoes: 1}, false},
+		{"argon2id pouca memória", ConfigHashSenha{Algoritmo: HashArgon2id, Argon2Memoria: 15, Argon2Iteracoes: 1, Argon2Paralelismo: 2}, false},
+		{"algoritmo desconhecido", ConfigHashSenha{Algoritmo: "md5"}, false},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if err := caso.config.Validar(); (err == nil) != caso.valido {
+				t.Errorf("Validar() = %v, esperado válido = %v", err, caso.valido)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// RetencaoLixeira é por quanto tempo um registro apagado fica na lixeira antes de ser
+// apagado definitivamente. Zero desativa a limpeza automática.
+var RetencaoLixeira = 30 * 24 * time.Hour
+
+// ItemLixeira é um registro apagado que ainda pode ser restaurado
+type ItemLixeira struct {
+	ID         uint       `json:"id"`
+	Descricao  string     `json:"descricao"`
+	UsuarioID  uint       `json:"usuario_id,omitempty"`
+	RemovidoEm time.Time  `json:"removido_em"`
+	PurgaEm    *time.Time `json:"purga_em,omitempty"`
+}
+
+// Lixeira agrupa os registros apagados por tipo
+type Lixeira struct {
+	Postagens []ItemLixeira `json:"postagens"`
+	Temas     []ItemLixeira `json:"temas,omitempty"`
+	Usuarios  []ItemLixeira `json:"usuarios,omitempty"`
+}
+
+// Erros da restauração de registros da lixeira
+var (
+	ErrRestauracaoTema    = errors.New("O Tema da Postagem está na lixeira! Restaure o Tema primeiro.")
+	ErrRestauracaoUsuario = errors.New("O Usuario da Postagem está na lixeira! Restaure o Usuario primeiro.")
+)
+
+// ListarLixeira retorna as Postagens apagadas do Usuario ou, se usuarioID for zero,
+// todas as Postagens, Temas e Usuarios apagados
+func ListarLixeira(db *gorm.DB, usuarioID uint) Lixeira {
+
+	lixeira := Lixeira{Postagens: []ItemLixeira{}}
+
+	consulta := db.Unscoped().Model(&model.Postagem{}).Select("id, titulo AS descricao, usuario_id, deleted_at AS removido_em").Where("deleted_at IS NOT NULL")
+	if usuarioID != 0 {
+		consulta = consulta.Where("usuario_id = ?", usuarioID)
+	}
+	consulta.Order("deleted_at DESC").Scan(&lixeira.Postagens)
+
+	if usuarioID == 0 {
+		lixeira.Temas = []ItemLixeira{}
+		lixeira.Usuarios = []ItemLixeira{}
+		db.Unscoped().Model(&model.Tema{}).Select("id, descricao, deleted_at AS removido_em").Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Scan(&lixeira.Temas)
+		db.Unscoped().Model(&model.Usuario{}).Select("id, usuario AS descricao, deleted_at AS removido_em").Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Scan(&lixeira.Usuarios)
+	}
+
+	for _, itens := range [][]ItemLixeira{lixeira.Postagens, lixeira.Temas, lixeira.Usuarios} {
+		for i := range itens {
+			if RetencaoLixeira > 0 {
+				purga := itens[i].RemovidoEm.Add(RetencaoLixeira)
+				itens[i].PurgaEm = &purga
+			}
+		}
+	}
+
+	return lixeira
+}
+
+// PostagemNaLixeira busca uma Postagem apagada pelo id
+func PostagemNaLixeira(db *gorm.DB, id uint) (model.Postagem, bool) {
+
+	var postagem model.Postagem
+	db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Find(&postagem)
+
+	return postagem, postagem.ID != 0
+}
+
+// RestaurarPostagem tira a Postagem da lixeira, desde que o Tema e o Usuario dela estejam ativos
+func RestaurarPostagem(db *gorm.DB, postagem model.Postagem) error {
+
+	var total int64
+	if db.Model(&model.Tema{}).Where("id = ?", postagem.TemaID).Count(&total); total == 0 {
+		return ErrRestauracaoTema
+	}
+	if db.Model(&model.Usuario{}).Where("id = ?", postagem.UsuarioID).Count(&total); total == 0 {
+		return ErrRestauracaoUsuario
+	}
+
+	return db.Unscoped().Model(&model.Postagem{}).Where("id = ?", postagem.ID).UpdateColumn("deleted_at", nil).Error
+}
+
+// RestaurarTema tira o Tema da lixeira. Se o Tema pai não estiver mais ativo, o Tema volta como raiz.
+func RestaurarTema(db *gorm.DB, id uint) error {
+
+	var tema model.Tema
+	if db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Find(&tema); tema.ID == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	colunas := map[string]interface{}{"deleted_at": nil}
+	if tema.ParentID != nil {
+		var total int64
+		if db.Model(&model.Tema{}).Where("id = ?", *tema.ParentID).Count(&total); total == 0 {
+			colunas["parent_id"] = nil
+		}
+	}
+
+	return db.Unscoped().Model(&model.Tema{}).Where("id = ?", id).UpdateColumns(colunas).Error
+}
+
+// RestaurarUsuario tira o Usuario da lixeira
+func RestaurarUsuario(db *gorm.DB, id uint) error {
+
+	result := db.Unscoped().Model(&model.Usuario{}).Where("id = ? AND deleted_at IS NOT NULL", id).UpdateColumn("deleted_at", nil)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return result.Error
+}
+
+// ResultadoLimpeza conta os registros apagados definitivamente
+type ResultadoLimpeza struct {
+	Postagens int64
+	Temas     int64
+	Usuarios  int64
+}
+
+// EsvaziarLixeira apaga definitivamente os registros que estão na lixeira desde antes do limite.
+// Temas e Usuarios que ainda são referenciados por alguma Postagem, mesmo na lixeira, ficam para a próxima limpeza.
+func EsvaziarLixeira(db *gorm.DB, limite time.Time) (ResultadoLimpeza, error) {
+
+	var resultado ResultadoLimpeza
+	postagens := model.Postagem{}.TableName()
+
+	result := db.Unscoped().Where("deleted_at < ?", limite).Delete(&model.Postagem{})
+	if result.Error != nil {
+		return resultado, result.Error
+	}
+	resultado.Postagens = result.RowsAffected
+
+	result = db.Unscoped().
+		Where("deleted_at < ?", limite).
+		Where("NOT EXISTS (SELECT 1 FROM " + postagens + " AS p WHERE p.tema_id = " + model.Tema{}.TableName() + ".id)").
+		Delete(&model.Tema{})
+	if result.Error != nil {
+		return resultado, result.Error
+	}
+	resultado.Temas = result.RowsAffected
+
+	result = db.Unscoped().
+		Where("deleted_at < ?", limite).
+		Where("NOT EXISTS (SELECT 1 FROM " + postagens + " AS p WHERE p.usuario_id = " + model.Usuario{}.TableName() + ".id)").
+		Delete(&model.Usuario{})
+	if result.Error != nil {
+		return resultado, result.Error
+	}
+	resultado.Usuarios = result.RowsAffected
+
+	return resultado, nil
+}
+
+// IniciarLimpezaLixeira esvazia a lixeira periodicamente em segundo plano,
+// apagando o que foi removido há mais tempo que RetencaoLixeira
+func IniciarLimpezaLixeira(db *gorm.DB, intervalo time.Duration) {
+
+	if RetencaoLixeira <= 0 || intervalo <= 0 {
+		return
+	}
+
+	go func() {
+		for {
+			resultado, err := EsvaziarLixeira(db, time.Now().Add(-RetencaoLixeira))
+			if err != nil {
+				log.Printf("Erro ao esvaziar a lixeira: %s", err)
+			} else if resultado.Postagens+resultado.Temas+resultado.Usuarios > 0 {
+				log.Printf("Lixeira esvaziada: %d postagens, %d temas e %d usuarios apagados definitivamente", resultado.Postagens, resultado.Temas, resultado.Usuarios)
+			}
+			time.Sleep(intervalo)
+		}
+	}()
+}
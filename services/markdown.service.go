@@ -0,0 +1,236 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"blogpessoal/cache"
+	"blogpessoal/model"
+
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// FrontMatter segue os nomes usados pelo Hugo e pelo Jekyll
+type FrontMatter struct {
+	Title  string    `yaml:"title"`
+	Slug   string    `yaml:"slug"`
+	Date   time.Time `yaml:"date"`
+	Author string    `yaml:"author"`
+	Tema   string    `yaml:"tema"`
+	Draft  bool      `yaml:"draft"`
+}
+
+const delimitadorFrontMatter = "---"
+
+// ExportarMarkdown grava cada Postagem em <diretorio>/<slug do tema>/<slug>.md
+func ExportarMarkdown(db *gorm.DB, diretorio string) (int, error) {
+
+	total := 0
+	var postagens []model.Postagem
+
+	result := db.Joins("Tema").Joins("Usuario").FindInBatches(&postagens, 500, func(tx *gorm.DB, lote int) error {
+		for _, postagem := range postagens {
+			pasta := filepath.Join(diretorio, postagem.Tema.Slug)
+			if err := os.MkdirAll(pasta, 0o755); err != nil {
+				return err
+			}
+
+			conteudo, err := gerarMarkdown(postagem)
+			if err != nil {
+				return err
+			}
+
+			if err := os.WriteFile(filepath.Join(pasta, postagem.Slug+".md"), conteudo, 0o644); err != nil {
+				return err
+			}
+			total++
+		}
+		return nil
+	})
+
+	return total, result.Error
+}
+
+func gerarMarkdown(postagem model.Postagem) ([]byte, error) {
+
+	frontMatter, err := yaml.Marshal(FrontMatter{
+		Title:  postagem.Titulo,
+		Slug:   postagem.Slug,
+		Date:   postagem.UpdatedAt,
+		Author: postagem.Usuario.Usuario,
+		Tema:   postagem.Tema.Descricao,
+		Draft:  postagem.Status == model.StatusRascunho,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var conteudo bytes.Buffer
+	conteudo.WriteString(delimitadorFrontMatter + "\n")
+	conteudo.Write(frontMatter)
+	conteudo.WriteString(delimitadorFrontMatter + "\n\n")
+	conteudo.WriteString(postagem.Texto)
+	conteudo.WriteString("\n")
+
+	return conteudo.Bytes(), nil
+}
+
+// ImportarMarkdown lê os arquivos .md do diretório e cria ou atualiza as Postagens pelo slug.
+// O Tema vem do front matter ou, na falta dele, do nome da pasta; o autor é o e-mail de um Usuario existente.
+func ImportarMarkdown(db *gorm.DB, diretorio string, dryRun bool) (RelatorioImportacao, error) {
+
+	var relatorio RelatorioImportacao
+
+	var arquivos []string
+	err := filepath.WalkDir(diretorio, func(caminho string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(caminho), ".md") {
+			arquivos = append(arquivos, caminho)
+		}
+		return nil
+	})
+	if err != nil {
+		return relatorio, err
+	}
+
+	err = cache.Transaction(db, func(tx *gorm.DB) error {
+		for i, caminho := range arquivos {
+			resultado := ResultadoLinha{Linha: i + 1}
+
+			status, id, err := importarArquivoMarkdown(tx, diretorio, caminho)
+			if err != nil {
+				resultado.Status = "erro"
+				resultado.Erro = fmt.Sprintf("%s: %s", caminho, err.Error())
+				relatorio.Falhas++
+			} else {
+				resultado.Status = status
+				resultado.ID = id
+				relatorio.Sucessos++
+			}
+			relatorio.Linhas = append(relatorio.Linhas, resultado)
+		}
+		relatorio.Total = len(arquivos)
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errDryRun) {
+		return relatorio, err
+	}
+
+	relatorio.Aplicado = !dryRun && relatorio.Sucessos > 0
+	return relatorio, nil
+}
+
+func importarArquivoMarkdown(tx *gorm.DB, diretorio string, caminho string) (string, uint, error) {
+
+	conteudo, err := os.ReadFile(caminho)
+	if err != nil {
+		return "", 0, err
+	}
+
+	frontMatter, texto, err := lerFrontMatter(conteudo)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var tema model.Tema
+	if frontMatter.Tema != "" {
+		tx.Where("descricao_normalizada = ?", model.NormalizarTexto(frontMatter.Tema)).Find(&tema)
+	}
+	if tema.ID == 0 {
+		pasta, _ := filepath.Rel(diretorio, filepath.Dir(caminho))
+		tx.Where("slug = ?", model.GerarSlug(filepath.Base(pasta))).Find(&tema)
+	}
+	if tema.ID == 0 {
+		return "", 0, errors.New("Tema Não Encontrado!")
+	}
+
+	var usuario model.Usuario
+	if frontMatter.Author != "" {
+		tx.Where("usuario = ?", model.NormalizarEmail(frontMatter.Author)).Find(&usuario)
+	}
+	if usuario.ID == 0 {
+		return "", 0, errors.New("Usuario Não Encontrado!")
+	}
+
+	slug := frontMatter.Slug
+	if slug == "" {
+		slug = strings.TrimSuffix(filepath.Base(caminho), filepath.Ext(caminho))
+	}
+
+	postagem := model.Postagem{
+		Titulo:    frontMatter.Title,
+		Texto:     texto,
+		Slug:      model.GerarSlug(slug),
+		Status:    model.StatusPublicado,
+		UpdatedAt: frontMatter.Date,
+		TemaID:    tema.ID,
+		UsuarioID: usuario.ID,
+	}
+	if frontMatter.Draft {
+		postagem.Status = model.StatusRascunho
+	}
+
+	if err := validator.New().Struct(postagem); err != nil {
+		return "", 0, err
+	}
+
+	status := "criada"
+	var existente model.Postagem
+	tx.Unscoped().Where("slug = ?", postagem.Slug).Find(&existente)
+	if existente.ID != 0 {
+		postagem.ID = existente.ID
+		status = "atualizada"
+	}
+
+	if err := salvarPostagem(tx, &postagem); err != nil {
+		return "", 0, err
+	}
+
+	return status, postagem.ID, nil
+}
+
+// lerFrontMatter separa o bloco YAML entre "---" do corpo do arquivo. O bloco termina na primeira
+// linha que contém apenas o delimitador, então "----" ou "--- x" dentro do YAML não o fecham.
+func lerFrontMatter(conteudo []byte) (FrontMatter, string, error) {
+
+	var frontMatter FrontMatter
+
+	texto := strings.ReplaceAll(string(bytes.TrimPrefix(conteudo, []byte("\xef\xbb\xbf"))), "\r\n", "\n")
+	if !strings.HasPrefix(texto, delimitadorFrontMatter+"\n") {
+		return frontMatter, "", errors.New("arquivo sem front matter")
+	}
+
+	linhas := strings.SplitAfter(texto[len(delimitadorFrontMatter)+1:], "\n")
+	fim := -1
+	for i, linha := range linhas {
+		if strings.TrimRight(linha, " \t\n") == delimitadorFrontMatter {
+			fim = i
+			break
+		}
+	}
+	if fim < 0 {
+		return frontMatter, "", errors.New("front matter não foi fechado")
+	}
+
+	if err := yaml.Unmarshal([]byte(strings.Join(linhas[:fim], "")), &frontMatter); err != nil {
+		return frontMatter, "", fmt.Errorf("front matter inválido: %w", err)
+	}
+
+	corpo := strings.Join(linhas[fim+1:], "")
+	return frontMatter, strings.TrimSpace(corpo), nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// CentralNotificacoes entrega as Notificacoes, assim que são criadas, aos clientes
+// conectados ao stream de cada Usuario
+type CentralNotificacoes struct {
+	mu         sync.Mutex
+	assinantes map[uint]map[chan model.Notificacao]struct{}
+}
+
+// Notificacoes é a central usada pelo servidor
+var Notificacoes = &CentralNotificacoes{assinantes: make(map[uint]map[chan model.Notificacao]struct{})}
+
+// Assinar registra um cliente que quer receber as Notificacoes do Usuario.
+// A função retornada cancela a assinatura e deve ser chamada quando o cliente desconectar.
+func (c *CentralNotificacoes) Assinar(usuarioID uint) (<-chan model.Notificacao, func()) {
+
+	canal := make(chan model.Notificacao, 16)
+
+	c.mu.Lock()
+	if c.assinantes[usuarioID] == nil {
+		c.assinantes[usuarioID] = make(map[chan model.Notificacao]struct{})
+	}
+	c.assinantes[usuarioID][canal] = struct{}{}
+	c.mu.Unlock()
+
+	return canal, func() {
+		c.mu.Lock()
+		delete(c.assinantes[usuarioID], canal)
+		if len(c.assinantes[usuarioID]) == 0 {
+			delete(c.assinantes, usuarioID)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// publicar envia a Notificacao aos clientes conectados do destinatário. Um cliente que
+// não consome o canal perde a Notificacao em tempo real, mas ela continua gravada.
+func (c *CentralNotificacoes) publicar(notificacao model.Notificacao) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for canal := range c.assinantes[notificacao.UsuarioID] {
+		select {
+		case canal <- notificacao:
+		default:
+		}
+	}
+}
+
+// Notificar grava as Notificacoes e as entrega em tempo real aos destinatários conectados
+func Notificar(db *gorm.DB, notificacoes []model.Notificacao) error {
+
+	if len(notificacoes) == 0 {
+		return nil
+	}
+
+	if err := db.Omit("Usuario").CreateInBatches(&notificacoes, 500).Error; err != nil {
+		return err
+	}
+
+	for _, notificacao := range notificacoes {
+		Notificacoes.publicar(notificacao)
+	}
+	return nil
+}
+
+// NotificarNovoSeguidor avisa o Usuario que ganhou um seguidor
+func NotificarNovoSeguidor(db *gorm.DB, seguidor model.Usuario, usuarioID uint) {
+
+	err := Notificar(db, []model.Notificacao{{
+		UsuarioID: usuarioID,
+		Tipo:      model.NotificacaoNovoSeguidor,
+		Mensagem:  fmt.Sprintf("%s começou a seguir você", seguidor.Nome),
+		AutorID:   &seguidor.ID,
+	}})
+	if err != nil {
+		log.Printf("Erro ao notificar o novo seguidor: %s", err)
+	}
+}
+
+// NotificarNovaPostagem avisa quem segue o autor ou o Tema de uma Postagem publicada
+func NotificarNovaPostagem(db *gorm.DB, postagem model.Postagem) {
+
+	if postagem.ID == 0 || postagem.Status != model.StatusPublicado {
+		return
+	}
+
+	var autor model.Usuario
+	db.Select("id", "nome").Find(&autor, postagem.UsuarioID)
+
+	var destinatarios []uint
+	db.Model(&model.SeguidorUsuario{}).Where("usuario_id = ?", postagem.UsuarioID).Pluck("seguidor_id", &destinatarios)
+
+	var seguidoresTema []uint
+	db.Model(&model.SeguidorTema{}).Where("tema_id = ?", postagem.TemaID).Pluck("usuario_id", &seguidoresTema)
+
+	vistos := map[uint]bool{postagem.UsuarioID: true}
+	var notificacoes []model.Notificacao
+
+	for _, usuarioID := range append(destinatarios, seguidoresTema...) {
+		if vistos[usuarioID] {
+			continue
+		}
+		vistos[usuarioID] = true
+		notificacoes = append(notificacoes, model.Notificacao{
+			UsuarioID:  usuarioID,
+			Tipo:       model.NotificacaoNovaPostagem,
+			Mensagem:   fmt.Sprintf("%s publicou \"%s\"", autor.Nome, postagem.Titulo),
+			AutorID:    &postagem.UsuarioID,
+			PostagemID: &postagem.ID,
+			TemaID:     &postagem.TemaID,
+		})
+	}
+
+	if err := Notificar(db, notificacoes); err != nil {
+		log.Printf("Erro ao notificar a nova postagem: %s", err)
+	}
+}
+
+// PaginaNotificacoes é uma página das Notificacoes do Usuario
+type PaginaNotificacoes struct {
+	Pagina       int                 `json:"pagina"`
+	Tamanho      int                 `json:"tamanho"`
+	Total        int64               `json:"total"`
+	NaoLidas     int64               `json:"nao_lidas"`
+	Notificacoes []model.Notificacao `json:"notificacoes"`
+}
+
+// ListarNotificacoes retorna as Notificacoes do Usuario, das mais recentes às mais antigas
+func ListarNotificacoes(db *gorm.DB, usuarioID uint, apenasNaoLidas bool, pagina int, tamanho int) PaginaNotificacoes {
+
+	resultado := PaginaNotificacoes{Pagina: pagina, Tamanho: tamanho, Notificacoes: []model.Notificacao{}}
+
+	consulta := db.Model(&model.Notificacao{}).Where("usuario_id = ?", usuarioID)
+	if apenasNaoLidas {
+		consulta = consulta.Where("lida = ?", false)
+	}
+	consulta = consulta.Session(&gorm.Session{})
+
+	consulta.Count(&resultado.Total)
+	consulta.Order("created_at DESC, id DESC").Offset((pagina - 1) * tamanho).Limit(tamanho).Find(&resultado.Notificacoes)
+	resultado.NaoLidas = TotalNaoLidas(db, usuarioID)
+
+	return resultado
+}
+
+// TotalNaoLidas conta as Notificacoes do Usuario que ainda não foram lidas
+func TotalNaoLidas(db *gorm.DB, usuarioID uint) int64 {
+
+	var total int64
+	db.Model(&model.Notificacao{}).Where("usuario_id = ? AND lida = ?", usuarioID, false).Count(&total)
+
+	return total
+}
+
+// MarcarComoLidas marca como lidas as Notificacoes informadas do Usuario ou,
+// se nenhum id for informado, todas elas. Retorna quantas foram marcadas.
+func MarcarComoLidas(db *gorm.DB, usuarioID uint, ids ...uint) (int64, error) {
+
+	consulta := db.Model(&model.Notificacao{}).Where("usuario_id = ? AND lida = ?", usuarioID, false)
+	if len(ids) > 0 {
+		consulta = consulta.Where("id IN ?", ids)
+	}
+
+	result := consulta.Updates(map[string]interface{}{"lida": true, "lida_em": gorm.Expr("NOW()")})
+	return result.RowsAffected, result.Error
+}
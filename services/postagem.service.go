@@ -0,0 +1,24 @@
+package services
+
+import (
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// PostagensVisiveis é o escopo das Postagens que o Usuario logado pode ler: as publicadas e os
+// próprios rascunhos. Administradores leem todas.
+func PostagensVisiveis(logado model.Usuario) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if logado.Perfil == model.PerfilAdmin {
+			return db
+		}
+		postagens := model.Postagem{}.TableName()
+		return db.Where(postagens+".status = ? OR "+postagens+".usuario_id = ?", model.StatusPublicado, logado.ID)
+	}
+}
+
+// PostagemVisivel informa se o Usuario logado pode ler a Postagem, com o mesmo critério de PostagensVisiveis
+func PostagemVisivel(logado model.Usuario, postagem model.Postagem) bool {
+	return postagem.Status == model.StatusPublicado || postagem.UsuarioID == logado.ID || logado.Perfil == model.PerfilAdmin
+}
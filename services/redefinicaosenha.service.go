@@ -0,0 +1,150 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	htmltemplate "html/template"
+	"net/url"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"blogpessoal/mail"
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// OpcoesRedefinicaoSenha configura o e-mail de redefinição de senha: o nome do blog, o endereço
+// da API, que serve a página de redefinição, e por quanto tempo o link vale
+type OpcoesRedefinicaoSenha struct {
+	Titulo   string
+	URLAPI   string
+	Validade time.Duration
+}
+
+// RedefinicaoSenha são as opções em vigor
+var RedefinicaoSenha = OpcoesRedefinicaoSenha{Titulo: "Blog Pessoal", URLAPI: "http://localhost:8080", Validade: 24 * time.Hour}
+
+// Erros da redefinição de senha
+var (
+	// ErrTokenRedefinicaoInvalido indica um link de redefinição inexistente, já usado ou expirado
+	ErrTokenRedefinicaoInvalido = errors.New("Link de redefinição de senha inválido ou expirado!")
+	// ErrEnvioRedefinicaoSenha indica que a senha foi bloqueada, mas o e-mail com o link não foi enviado
+	ErrEnvioRedefinicaoSenha = errors.New("A senha foi bloqueada, mas não foi possível enviar o e-mail de redefinição! Tente novamente.")
+)
+
+// gerarTokenRedefinicao retorna o token enviado por e-mail e o hash dele, que é o que fica gravado
+func gerarTokenRedefinicao() (string, string, error) {
+
+	aleatorio := make([]byte, 32)
+	if _, err := rand.Read(aleatorio); err != nil {
+		return "", "", err
+	}
+
+	token := hex.EncodeToString(aleatorio)
+	return token, hashTokenRedefinicao(token), nil
+}
+
+func hashTokenRedefinicao(token string) string {
+	soma := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(soma[:])
+}
+
+// UsuarioDoTokenRedefinicao busca o Usuario dono de um token de redefinição ainda válido
+func UsuarioDoTokenRedefinicao(db *gorm.DB, token string) (model.Usuario, error) {
+
+	var usuario model.Usuario
+	if token == "" {
+		return usuario, ErrTokenRedefinicaoInvalido
+	}
+
+	db.Where("token_redefinicao_senha = ? AND redefinicao_senha_expira_em > ?", hashTokenRedefinicao(token), time.Now()).Find(&usuario)
+	if usuario.ID == 0 {
+		return usuario, ErrTokenRedefinicaoInvalido
+	}
+
+	return usuario, nil
+}
+
+// RedefinirSenhaComToken grava o hash da nova senha do Usuario dono do token, que só pode ser usado
+// uma vez. Todas as Sessoes e tokens de acesso emitidos antes deixam de valer.
+func RedefinirSenhaComToken(db *gorm.DB, usuario model.Usuario, hash string) error {
+
+	// A condição no token impede que dois pedidos simultâneos usem o mesmo link
+	result := db.Model(&model.Usuario{}).Where("id = ? AND token_redefinicao_senha = ?", usuario.ID, usuario.TokenRedefinicaoSenha).UpdateColumns(map[string]interface{}{
+		"senha":                       hash,
+		"redefinir_senha":             false,
+		"token_redefinicao_senha":     "",
+		"redefinicao_senha_expira_em": nil,
+		"versao_token":                gorm.Expr("versao_token + 1"),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenRedefinicaoInvalido
+	}
+
+	return EncerrarSessoes(db, usuario.ID)
+}
+
+// enviarRedefinicaoSenha manda ao Usuario o link de uso único para escolher uma nova senha
+func enviarRedefinicaoSenha(mailer mail.Mailer, usuario model.Usuario, token string, expiraEm time.Time) error {
+
+	if mailer == nil {
+		return errors.New("nenhum mailer configurado")
+	}
+
+	dados := dadosRedefinicaoSenha{
+		Titulo:   RedefinicaoSenha.Titulo,
+		Nome:     usuario.Nome,
+		Link:     strings.TrimRight(RedefinicaoSenha.URLAPI, "/") + "/usuarios/senha/redefinir?token=" + url.QueryEscape(token),
+		ExpiraEm: expiraEm.Format("02/01/2006 15:04"),
+	}
+
+	var texto, html bytes.Buffer
+	if err := templateRedefinicaoTexto.Execute(&texto, dados); err != nil {
+		return err
+	}
+	if err := templateRedefinicaoHTML.Execute(&html, dados); err != nil {
+		return err
+	}
+
+	return mailer.Enviar(mail.Mensagem{
+		Para:    usuario.Usuario,
+		Assunto: RedefinicaoSenha.Titulo + ": redefina a sua senha",
+		Texto:   texto.String(),
+		HTML:    html.String(),
+	})
+}
+
+type dadosRedefinicaoSenha struct {
+	Titulo   string
+	Nome     string
+	Link     string
+	ExpiraEm string
+}
+
+var templateRedefinicaoTexto = texttemplate.Must(texttemplate.New("redefinicao").Parse(`Olá, {{.Nome}}!
+
+Um administrador de {{.Titulo}} bloqueou a sua senha atual e encerrou todas as suas sessões.
+Para voltar a entrar, escolha uma nova senha neste link, válido até {{.ExpiraEm}}:
+{{.Link}}
+
+O link só pode ser usado uma vez.
+`))
+
+var templateRedefinicaoHTML = htmltemplate.Must(htmltemplate.New("redefinicao").Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<body style="font-family: sans-serif; max-width: 600px; margin: auto">
+<p>Olá, {{.Nome}}!</p>
+<p>Um administrador de {{.Titulo}} bloqueou a sua senha atual e encerrou todas as suas sessões.
+Para voltar a entrar, <a href="{{.Link}}">escolha uma nova senha</a> até {{.ExpiraEm}}.</p>
+<p style="font-size: small; color: #666">O link só pode ser usado uma vez.</p>
+</body>
+</html>
+`))
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	htmltemplate "html/template"
+	"log"
+	"net/url"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"blogpessoal/mail"
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// Máximo de Postagens listadas em um resumo
+const postagensPorResumo = 50
+
+// OpcoesResumo configura o conteúdo do resumo por e-mail: o endereço do site, usado nos
+// links das Postagens, e o da API, usado no link de cancelamento
+type OpcoesResumo struct {
+	Titulo string
+	URL    string
+	URLAPI string
+}
+
+// periodosResumo é o intervalo mínimo entre dois resumos de cada frequência
+var periodosResumo = map[string]time.Duration{
+	model.FrequenciaDiaria:  24 * time.Hour,
+	model.FrequenciaSemanal: 7 * 24 * time.Hour,
+}
+
+// AssinaturaDoUsuario retorna a preferência de resumo do Usuario, criando a assinatura
+// semanal padrão na primeira vez
+func AssinaturaDoUsuario(db *gorm.DB, usuarioID uint) (model.AssinaturaResumo, error) {
+
+	var assinatura model.AssinaturaResumo
+	db.Where("usuario_id = ?", usuarioID).Find(&assinatura)
+	if assinatura.UsuarioID != 0 {
+		return assinatura, nil
+	}
+
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return assinatura, err
+	}
+
+	assinatura = model.AssinaturaResumo{UsuarioID: usuarioID, Frequencia: model.FrequenciaSemanal, Token: hex.EncodeToString(token)}
+	err := db.Omit("Usuario").Create(&assinatura).Error
+	return assinatura, err
+}
+
+// AlterarFrequenciaResumo grava a frequência do resumo escolhida pelo Usuario
+func AlterarFrequenciaResumo(db *gorm.DB, usuarioID uint, frequencia string) (model.AssinaturaResumo, error) {
+
+	assinatura, err := AssinaturaDoUsuario(db, usuarioID)
+	if err != nil {
+		return assinatura, err
+	}
+
+	assinatura.Frequencia = frequencia
+	err = db.Model(&assinatura).UpdateColumn("frequencia", frequencia).Error
+	return assinatura, err
+}
+
+// CancelarResumo desativa o resumo da assinatura dona do token do link de cancelamento
+func CancelarResumo(db *gorm.DB, token string) error {
+
+	if token == "" {
+		return gorm.ErrRecordNotFound
+	}
+
+	result := db.Model(&model.AssinaturaResumo{}).Where("token = ?", token).UpdateColumn("frequencia", model.FrequenciaNunca)
+	if result.Error == nil && result.RowsAffected == 0 {
+		var total int64
+		if db.Model(&model.AssinaturaResumo{}).Where("token = ?", token).Count(&total); total == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+
+	return result.Error
+}
+
+// EnviarResumos envia, a cada Usuario cujo resumo venceu, as Postagens publicadas pelos autores
+// e nos Temas que ele segue desde o último resumo. Contas banidas, pendentes ou suspensas não recebem nada. Vale a data da primeira publicação no blog,
+// então edições e datas preservadas pelas importações não mudam o que entra no resumo. Retorna quantos e-mails foram enviados.
+func EnviarResumos(db *gorm.DB, mailer mail.Mailer, opcoes OpcoesResumo, agora time.Time) (int, error) {
+
+	// Só as contas ativas recebem o resumo; as suspensões que já terminaram contam como ativas
+	var usuarios []model.Usuario
+	err := db.Select("id", "nome", "usuario").
+		Where("situacao = ? OR (situacao = ? AND suspenso_ate <= ?)", model.SituacaoAtivo, model.SituacaoSuspenso, agora).
+		Where("id IN (?) OR id IN (?)",
+			db.Model(&model.SeguidorUsuario{}).Select("seguidor_id"),
+			db.Model(&model.SeguidorTema{}).Select("usuario_id")).
+		Find(&usuarios).Error
+	if err != nil {
+		return 0, err
+	}
+
+	enviados := 0
+	for _, usuario := range usuarios {
+		assinatura, err := AssinaturaDoUsuario(db, usuario.ID)
+		if err != nil {
+			return enviados, err
+		}
+
+		periodo, ativa := periodosResumo[assinatura.Frequencia]
+		if !ativa {
+			continue
+		}
+
+		desde := agora.Add(-periodo)
+		if assinatura.UltimoEnvio != nil {
+			if agora.Sub(*assinatura.UltimoEnvio) < periodo {
+				continue
+			}
+			desde = *assinatura.UltimoEnvio
+		}
+
+		var postagens []model.Postagem
+		db.Joins("Tema").Joins("Usuario").
+			Where(model.Postagem{}.TableName()+".status = ?", model.StatusPublicado).
+			Where(model.Postagem{}.TableName()+".usuario_id <> ?", usuario.ID).
+			Where("publicada_em > ? AND publicada_em <= ?", desde, agora).
+			Where(condicaoSeguidos(db, usuario.ID)).
+			Order("publicada_em DESC").
+			Limit(postagensPorResumo).
+			Find(&postagens)
+
+		if len(postagens) > 0 {
+			mensagem, err := montarResumo(usuario, assinatura, postagens, opcoes)
+			if err != nil {
+				return enviados, err
+			}
+			if err := mailer.Enviar(mensagem); err != nil {
+				log.Printf("Erro ao enviar o resumo para %s: %s", usuario.Usuario, err)
+				continue
+			}
+			enviados++
+		}
+
+		db.Model(&assinatura).UpdateColumn("ultimo_envio", agora)
+	}
+
+	return enviados, nil
+}
+
+// IniciarEnvioResumos verifica periodicamente, em segundo plano, quais resumos venceram e os envia
+func IniciarEnvioResumos(db *gorm.DB, mailer mail.Mailer, opcoes OpcoesResumo, intervalo time.Duration) {
+
+	if mailer == nil || intervalo <= 0 {
+		return
+	}
+
+	go func() {
+		for {
+			enviados, err := EnviarResumos(db, mailer, opcoes, time.Now())
+			if err != nil {
+				log.Printf("Erro ao enviar os resumos: %s", err)
+			} else if enviados > 0 {
+				log.Printf("%d resumos enviados", enviados)
+			}
+			time.Sleep(intervalo)
+		}
+	}()
+}
+
+type dadosResumo struct {
+	Titulo      string
+	URL         string
+	Nome        string
+	Postagens   []model.Postagem
+	Cancelar    string
+	Preferencia string
+}
+
+func montarResumo(usuario model.Usuario, assinatura model.AssinaturaResumo, postagens []model.Postagem, opcoes OpcoesResumo) (mail.Mensagem, error) {
+
+	raiz := strings.TrimRight(opcoes.URL, "/")
+	cancelar := strings.TrimRight(opcoes.URLAPI, "/") + "/resumos/cancelar?token=" + url.QueryEscape(assinatura.Token)
+
+	dados := dadosResumo{
+		Titulo:      opcoes.Titulo,
+		URL:         raiz,
+		Nome:        usuario.Nome,
+		Postagens:   postagens,
+		Cancelar:    cancelar,
+		Preferencia: assinatura.Frequencia,
+	}
+
+	var texto, html bytes.Buffer
+	if err := templateResumoTexto.Execute(&texto, dados); err != nil {
+		return mail.Mensagem{}, err
+	}
+	if err := templateResumoHTML.Execute(&html, dados); err != nil {
+		return mail.Mensagem{}, err
+	}
+
+	return mail.Mensagem{
+		Para:    usuario.Usuario,
+		Assunto: opcoes.Titulo + ": novidades de quem você segue",
+		Texto:   texto.String(),
+		HTML:    html.String(),
+		Cabecalhos: map[string]string{
+			"List-Unsubscribe":      "<" + cancelar + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
+
+var templateResumoTexto = texttemplate.Must(texttemplate.New("resumo").Parse(`Olá, {{.Nome}}!
+
+Estas são as novidades de quem você segue em {{.Titulo}}:
+{{range .Postagens}}
+* {{.Titulo}} ({{.Tema.Descricao}}), por {{.Usuario.Nome}} {{with .PublicadaEm}}em {{.Format "02/01/2006"}}{{end}}
+  {{$.URL}}/postagens/{{.Slug}}/
+{{end}}
+Você recebe este resumo com frequência {{.Preferencia}}. Para não recebê-lo mais, acesse:
+{{.Cancelar}}
+`))
+
+var templateResumoHTML = htmltemplate.Must(htmltemplate.New("resumo").Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<body style="font-family: sans-serif; max-width: 600px; margin: auto">
+<p>Olá, {{.Nome}}!</p>
+<p>Estas são as novidades de quem você segue em <a href="{{.URL}}">{{.Titulo}}</a>:</p>
+<ul>
+{{range .Postagens}}<li><a href="{{$.URL}}/postagens/{{.Slug}}/">{{.Titulo}}</a> ({{.Tema.Descricao}}), por {{.Usuario.Nome}} {{with .PublicadaEm}}em {{.Format "02/01/2006"}}{{end}}</li>
+{{end}}</ul>
+<p style="font-size: small; color: #666">Você recebe este resumo com frequência {{.Preferencia}}.
+<a href="{{.Cancelar}}">Cancelar o resumo</a></p>
+</body>
+</html>
+`))
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrSeguirASiMesmo é retornado quando o Usuario tenta seguir a si mesmo
+var ErrSeguirASiMesmo = errors.New("Não é possível seguir a si mesmo!")
+
+// Seguidores lista quem segue um Usuario ou um Tema
+type Seguidores struct {
+	Total    int64                 `json:"total"`
+	Usuarios []model.UsuarioResumo `json:"usuarios"`
+}
+
+// Seguindo lista os Usuarios e Temas seguidos por um Usuario
+type Seguindo struct {
+	TotalUsuarios int64                 `json:"total_usuarios"`
+	TotalTemas    int64                 `json:"total_temas"`
+	Usuarios      []model.UsuarioResumo `json:"usuarios"`
+	Temas         []model.TemaResumo    `json:"temas"`
+}
+
+// SeguirUsuario faz o seguidor seguir o Usuario e indica se o seguimento é novo;
+// seguir de novo não tem efeito
+func SeguirUsuario(db *gorm.DB, seguidorID uint, usuarioID uint) (bool, error) {
+
+	if seguidorID == usuarioID {
+		return false, ErrSeguirASiMesmo
+	}
+
+	seguimento := model.SeguidorUsuario{SeguidorID: seguidorID, UsuarioID: usuarioID}
+	result := db.Where(&seguimento).Omit("Seguidor", "Usuario").FirstOrCreate(&seguimento)
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeixarDeSeguirUsuario desfaz o seguimento; deixar de seguir quem não é seguido não tem efeito
+func DeixarDeSeguirUsuario(db *gorm.DB, seguidorID uint, usuarioID uint) error {
+	return db.Where("seguidor_id = ? AND usuario_id = ?", seguidorID, usuarioID).Delete(&model.SeguidorUsuario{}).Error
+}
+
+// SeguirTema faz o Usuario seguir o Tema; seguir de novo não tem efeito
+func SeguirTema(db *gorm.DB, usuarioID uint, temaID uint) error {
+
+	seguimento := model.SeguidorTema{UsuarioID: usuarioID, TemaID: temaID}
+	return db.Where(&seguimento).Omit("Usuario", "Tema").FirstOrCreate(&seguimento).Error
+}
+
+// DeixarDeSeguirTema desfaz o seguimento; deixar de seguir um Tema não seguido não tem efeito
+func DeixarDeSeguirTema(db *gorm.DB, usuarioID uint, temaID uint) error {
+	return db.Where("usuario_id = ? AND tema_id = ?", usuarioID, temaID).Delete(&model.SeguidorTema{}).Error
+}
+
+// SeguidoresUsuario lista os Usuarios ativos e visíveis (nem banidos, nem pendentes) que seguem o Usuario, dos mais recentes aos mais antigos
+func SeguidoresUsuario(db *gorm.DB, usuarioID uint) Seguidores {
+
+	usuarios := model.Usuario{}.TableName()
+
+	seguidores := Seguidores{Usuarios: []model.UsuarioResumo{}}
+	db.Model(&model.Usuario{}).Scopes(UsuariosVisiveis).
+		Select(usuarios+".id, "+usuarios+".nome, "+usuarios+".slug, "+usuarios+".foto").
+		Joins("JOIN "+model.SeguidorUsuario{}.TableName()+" AS s ON s.seguidor_id = "+usuarios+".id").
+		Where("s.usuario_id = ?", usuarioID).
+		Order("s.created_at DESC").
+		Scan(&seguidores.Usuarios)
+	seguidores.Total = int64(len(seguidores.Usuarios))
+
+	return seguidores
+}
+
+// SeguidoresTema lista os Usuarios ativos que seguem o Tema, dos mais recentes aos mais antigos
+func SeguidoresTema(db *gorm.DB, temaID uint) Seguidores {
+
+	usuarios := model.Usuario{}.TableName()
+
+	seguidores := Seguidores{Usuarios: []model.UsuarioResumo{}}
+	db.Model(&model.Usuario{}).Scopes(UsuariosVisiveis).
+		Select(usuarios+".id, "+usuarios+".nome, "+usuarios+".slug, "+usuarios+".foto").
+		Joins("JOIN "+model.SeguidorTema{}.TableName()+" AS s ON s.usuario_id = "+usuarios+".id").
+		Where("s.tema_id = ?", temaID).
+		Order("s.created_at DESC").
+		Scan(&seguidores.Usuarios)
+	seguidores.Total = int64(len(seguidores.Usuarios))
+
+	return seguidores
+}
+
+// SeguidosPorUsuario lista os Usuarios e os Temas ativos seguidos pelo Usuario
+func SeguidosPorUsuario(db *gorm.DB, usuarioID uint) Seguindo {
+
+	usuarios := model.Usuario{}.TableName()
+	temas := model.Tema{}.TableName()
+
+	seguindo := Seguindo{Usuarios: []model.UsuarioResumo{}, Temas: []model.TemaResumo{}}
+	db.Model(&model.Usuario{}).Scopes(UsuariosVisiveis).
+		Select(usuarios+".id, "+usuarios+".nome, "+usuarios+".slug, "+usuarios+".foto").
+		Joins("JOIN "+model.SeguidorUsuario{}.TableName()+" AS s ON s.usuario_id = "+usuarios+".id").
+		Where("s.seguidor_id = ?", usuarioID).
+		Order("s.created_at DESC").
+		Scan(&seguindo.Usuarios)
+	db.Model(&model.Tema{}).
+		Select(temas+".id, "+temas+".descricao, "+temas+".slug").
+		Joins("JOIN "+model.SeguidorTema{}.TableName()+" AS s ON s.tema_id = "+temas+".id").
+		Where("s.usuario_id = ?", usuarioID).
+		Order("s.created_at DESC").
+		Scan(&seguindo.Temas)
+	seguindo.TotalUsuarios = int64(len(seguindo.Usuarios))
+	seguindo.TotalTemas = int64(len(seguindo.Temas))
+
+	return seguindo
+}
+
+// PaginaFeed é uma página da linha do tempo do Usuario
+type PaginaFeed struct {
+	Pagina    int                      `json:"pagina"`
+	Tamanho   int                      `json:"tamanho"`
+	Total     int64                    `json:"total"`
+	Postagens []model.PostagemResposta `json:"postagens"`
+}
+
+// Feed retorna as Postagens publicadas pelos autores e nos Temas que o Usuario segue,
+// das mais recentes às mais antigas
+func Feed(db *gorm.DB, usuarioID uint, pagina int, tamanho int) PaginaFeed {
+
+	feed := PaginaFeed{Pagina: pagina, Tamanho: tamanho}
+
+	consulta := db.Model(&model.Postagem{}).
+		Where(model.Postagem{}.TableName()+".status = ?", model.StatusPublicado).
+		Where(condicaoSeguidos(db, usuarioID)).
+		Session(&gorm.Session{})
+
+	consulta.Count(&feed.Total)
+	var postagens []model.Postagem
+	consulta.Joins("Tema").Joins("Usuario").
+		Order("data DESC, " + model.Postagem{}.TableName() + ".id DESC").
+		Offset((pagina - 1) * tamanho).
+		Limit(tamanho).
+		Find(&postagens)
+
+	PreencherBreadcrumbs(db, postagens)
+	feed.Postagens = model.PostagensResposta(postagens)
+
+	return feed
+}
+
+// condicaoSeguidos filtra as Postagens dos autores ou dos Temas que o Usuario segue
+func condicaoSeguidos(db *gorm.DB, usuarioID uint) *gorm.DB {
+
+	autores := db.Model(&model.SeguidorUsuario{}).Select("usuario_id").Where("seguidor_id = ?", usuarioID)
+	temas := db.Model(&model.SeguidorTema{}).Select("tema_id").Where("usuario_id = ?", usuarioID)
+
+	return db.Where(model.Postagem{}.TableName()+".usuario_id IN (?)", autores).Or(model.Postagem{}.TableName()+".tema_id IN (?)", temas)
+}
+
+// moverSeguidoresTema passa os seguidores do Tema de origem para o Tema de destino,
+// sem duplicar quem já segue os dois
+func moverSeguidoresTema(tx *gorm.DB, origemID uint, destinoID uint) error {
+
+	// O MySQL não aceita apagar de uma tabela consultando ela mesma, daí a tabela derivada
+	seguidoresDestino := tx.Table("(?) AS d", tx.Model(&model.SeguidorTema{}).Select("usuario_id").Where("tema_id = ?", destinoID)).Select("usuario_id")
+
+	if err := tx.Where("tema_id = ? AND usuario_id IN (?)", origemID, seguidoresDestino).Delete(&model.SeguidorTema{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&model.SeguidorTema{}).Where("tema_id = ?", origemID).UpdateColumn("tema_id", destinoID).Error
+}
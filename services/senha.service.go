@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"blogpessoal/model"
+)
+
+// PoliticaSenha define as regras que uma nova senha precisa cumprir
+type PoliticaSenha struct {
+	TamanhoMinimo   int
+	ExigirMaiuscula bool
+	ExigirMinuscula bool
+	ExigirNumero    bool
+	ExigirSimbolo   bool
+	// Recusa senhas que contêm o e-mail ou partes do nome do Usuario
+	ProibirDadosPessoais bool
+}
+
+// PoliticaSenhas é a política aplicada no cadastro e na troca de senha
+var PoliticaSenhas = PoliticaSenha{TamanhoMinimo: 8, ProibirDadosPessoais: true}
+
+// Partes do nome e do e-mail menores que isso não são procuradas na senha
+const tamanhoMinimoDadoPessoal = 4
+
+// Erros da política de senhas
+var (
+	ErrSenhaSemMaiuscula  = errors.New("A senha deve ter pelo menos uma letra maiúscula!")
+	ErrSenhaSemMinuscula  = errors.New("A senha deve ter pelo menos uma letra minúscula!")
+	ErrSenhaSemNumero     = errors.New("A senha deve ter pelo menos um número!")
+	ErrSenhaSemSimbolo    = errors.New("A senha deve ter pelo menos um símbolo!")
+	ErrSenhaDadosPessoais = errors.New("A senha não pode conter o seu nome ou o seu e-mail!")
+	ErrSenhaVazada        = errors.New("Esta senha aparece em vazamentos de dados conhecidos! Escolha outra.")
+	ErrSenhaNaoAlterada   = errors.New("A nova senha deve ser diferente da senha atual!")
+	ErrVerificacaoVazadas = errors.New("Não foi possível verificar a senha! Tente novamente.")
+)
+
+// ValidarSenha aplica a política de senhas e a lista de senhas vazadas à senha escolhida pelo Usuario
+func ValidarSenha(senha string, usuario model.Usuario) error {
+
+	if err := PoliticaSenhas.Validar(senha, usuario); err != nil {
+		return err
+	}
+
+	vazada, err := SenhasVazadas.Contem(senha)
+	if err != nil {
+		return ErrVerificacaoVazadas
+	}
+	if vazada {
+		return ErrSenhaVazada
+	}
+
+	return nil
+}
+
+// Validar confere o tamanho, as classes de caracteres e os dados pessoais da senha
+func (politica PoliticaSenha) Validar(senha string, usuario model.Usuario) error {
+
+	if utf8.RuneCountInString(senha) < politica.TamanhoMinimo {
+		return fmt.Errorf("A senha deve ter pelo menos %d caracteres!", politica.TamanhoMinimo)
+	}
+
+	var maiuscula, minuscula, numero, simbolo bool
+	for _, caractere := range senha {
+		switch {
+		case unicode.IsUpper(caractere):
+			maiuscula = true
+		case unicode.IsLower(caractere):
+			minuscula = true
+		case unicode.IsDigit(caractere):
+			numero = true
+		case unicode.IsPunct(caractere), unicode.IsSymbol(caractere), unicode.IsSpace(caractere):
+			simbolo = true
+		}
+	}
+
+	switch {
+	case politica.ExigirMaiuscula && !maiuscula:
+		return ErrSenhaSemMaiuscula
+	case politica.ExigirMinuscula && !minuscula:
+		return ErrSenhaSemMinuscula
+	case politica.ExigirNumero && !numero:
+		return ErrSenhaSemNumero
+	case politica.ExigirSimbolo && !simbolo:
+		return ErrSenhaSemSimbolo
+	}
+
+	if politica.ProibirDadosPessoais && contemDadosPessoais(senha, usuario) {
+		return ErrSenhaDadosPessoais
+	}
+
+	return nil
+}
+
+// contemDadosPessoais procura na senha, sem diferenciar maiúsculas, o e-mail, o usuário
+// antes do @ e as palavras do nome
+func contemDadosPessoais(senha string, usuario model.Usuario) bool {
+
+	senha = strings.ToLower(senha)
+	email := strings.ToLower(strings.TrimSpace(usuario.Usuario))
+
+	dados := []string{email}
+	if local, _, ok := strings.Cut(email, "@"); ok {
+		dados = append(dados, local)
+		dados = append(dados, strings.FieldsFunc(local, func(caractere rune) bool {
+			return !unicode.IsLetter(caractere) && !unicode.IsDigit(caractere)
+		})...)
+	}
+	dados = append(dados, strings.Fields(strings.ToLower(usuario.Nome))...)
+
+	for _, dado := range dados {
+		if utf8.RuneCountInString(dado) >= tamanhoMinimoDadoPessoal && strings.Contains(senha, dado) {
+			return true
+		}
+	}
+
+	return false
+}
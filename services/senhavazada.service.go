@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Tamanho do prefixo do hash usado para localizar a faixa, como na API de faixas do Have I Been Pwned
+const tamanhoPrefixoSenhaVazada = 5
+
+// Quantidade de prefixos possíveis com 5 dígitos hexadecimais
+const totalPrefixosSenhaVazada = 1 << (4 * tamanhoPrefixoSenhaVazada)
+
+// ListaSenhasVazadas consulta um arquivo local com os hashes SHA-1 de senhas vazadas, no formato
+// das listas do Have I Been Pwned: uma linha "HASH" ou "HASH:OCORRENCIAS" por senha, ordenadas pelo hash.
+// A busca segue o k-anonimato da API do serviço: o prefixo do hash localiza a faixa e apenas
+// os sufixos dessa faixa são lidos do disco e comparados, sem carregar o arquivo na memória.
+type ListaSenhasVazadas struct {
+	arquivo *os.File
+	// Posição no arquivo da primeira linha de cada prefixo; a última posição é o fim do arquivo
+	inicios []int64
+}
+
+// SenhasVazadas é a lista consultada por ValidarSenha. Sem lista, nenhuma senha é recusada por ela.
+var SenhasVazadas *ListaSenhasVazadas
+
+// CarregarSenhasVazadas abre o arquivo de hashes e indexa a posição de cada prefixo.
+// O arquivo é lido uma vez por inteiro e precisa estar ordenado pelo hash.
+func CarregarSenhasVazadas(caminho string) (*ListaSenhasVazadas, error) {
+
+	arquivo, err := os.Open(caminho)
+	if err != nil {
+		return nil, err
+	}
+
+	lista := &ListaSenhasVazadas{arquivo: arquivo, inicios: make([]int64, totalPrefixosSenhaVazada+1)}
+	for i := range lista.inicios {
+		lista.inicios[i] = -1
+	}
+
+	leitor := bufio.NewReaderSize(arquivo, 1<<20)
+	var posicao int64
+	ultimo := -1
+
+	for numero := 1; ; numero++ {
+		linha, err := leitor.ReadString('\n')
+		if strings.TrimSpace(linha) != "" {
+			prefixo, ok := prefixoSenhaVazada(linha)
+			if !ok {
+				arquivo.Close()
+				return nil, fmt.Errorf("%s:%d: linha inválida, esperado um hash SHA-1", caminho, numero)
+			}
+			if prefixo < ultimo {
+				arquivo.Close()
+				return nil, fmt.Errorf("%s:%d: o arquivo não está ordenado pelo hash", caminho, numero)
+			}
+			if prefixo != ultimo {
+				lista.inicios[prefixo] = posicao
+				ultimo = prefixo
+			}
+		}
+		posicao += int64(len(linha))
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			arquivo.Close()
+			return nil, err
+		}
+	}
+
+	// Prefixos sem nenhum hash começam onde começa o próximo, formando uma faixa vazia
+	lista.inicios[totalPrefixosSenhaVazada] = posicao
+	for i := totalPrefixosSenhaVazada - 1; i >= 0; i-- {
+		if lista.inicios[i] < 0 {
+			lista.inicios[i] = lista.inicios[i+1]
+		}
+	}
+
+	return lista, nil
+}
+
+// Faixa retorna os sufixos, em maiúsculas, dos hashes que começam com o prefixo de 5 dígitos hexadecimais
+func (lista *ListaSenhasVazadas) Faixa(prefixo string) ([]string, error) {
+
+	indice, err := strconv.ParseUint(prefixo, 16, 32)
+	if err != nil || len(prefixo) != tamanhoPrefixoSenhaVazada {
+		return nil, fmt.Errorf("prefixo inválido: %q", prefixo)
+	}
+
+	inicio, fim := lista.inicios[indice], lista.inicios[indice+1]
+	conteudo := make([]byte, fim-inicio)
+	if _, err := lista.arquivo.ReadAt(conteudo, inicio); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	sufixos := []string{}
+	for _, linha := range strings.Split(string(conteudo), "\n") {
+		hash, _, _ := strings.Cut(strings.TrimSpace(linha), ":")
+		if len(hash) == sha1.Size*2 {
+			sufixos = append(sufixos, strings.ToUpper(hash[tamanhoPrefixoSenhaVazada:]))
+		}
+	}
+
+	return sufixos, nil
+}
+
+// Contem informa se a senha está na lista de senhas vazadas
+func (lista *ListaSenhasVazadas) Contem(senha string) (bool, error) {
+
+	if lista == nil {
+		return false, nil
+	}
+
+	soma := sha1.Sum([]byte(senha))
+	hash := strings.ToUpper(hex.EncodeToString(soma[:]))
+
+	sufixos, err := lista.Faixa(hash[:tamanhoPrefixoSenhaVazada])
+	if err != nil {
+		return false, err
+	}
+
+	for _, sufixo := range sufixos {
+		if sufixo == hash[tamanhoPrefixoSenhaVazada:] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// prefixoSenhaVazada lê o prefixo de uma linha do arquivo de hashes
+func prefixoSenhaVazada(linha string) (int, bool) {
+
+	hash, _, _ := strings.Cut(strings.TrimSpace(linha), ":")
+	if len(hash) != sha1.Size*2 {
+		return 0, false
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return 0, false
+	}
+
+	prefixo, _ := strconv.ParseUint(hash[:tamanhoPrefixoSenhaVazada], 16, 32)
+	return int(prefixo), true
+}
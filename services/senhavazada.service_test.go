@@ -0,0 +1,142 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func sha1Senha(senha string) string {
+	soma := sha1.Sum([]byte(senha))
+	return strings.ToUpper(hex.EncodeToString(soma[:]))
+}
+
+// carregarListaTeste grava o conteúdo em um arquivo temporário e o carrega
+func carregarListaTeste(t *testing.T, conteudo string) (*ListaSenhasVazadas, error) {
+	t.Helper()
+
+	caminho := filepath.Join(t.TempDir(), "senhas.txt")
+	if err := os.WriteFile(caminho, []byte(conteudo), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	lista, err := CarregarSenhasVazadas(caminho)
+	if lista != nil {
+		t.Cleanup(func() { lista.arquivo.Close() })
+	}
+	return lista, err
+}
+
+func TestListaSenhasVazadas(t *testing.T) {
+
+	// 5BAA6... é "password"; as demais linhas cobrem o primeiro e o último prefixo e
+	// dois hashes com o mesmo prefixo
+	linhas := []string{
+		"0000000000000000000000000000000000000001:3",
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824",
+		"5BAA6FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:1",
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+	}
+
+	formatos := []struct {
+		nome     string
+		conteudo string
+	}{
+		{"maiúsculas", strings.Join(linhas, "\n") + "\n"},
+		{"sem quebra de linha no final", strings.Join(linhas, "\n")},
+		{"minúsculas e CRLF", strings.ToLower(strings.Join(linhas, "\r\n")) + "\r\n"},
+		{"linhas em branco", "\n" + strings.Join(linhas, "\n\n") + "\n\n"},
+	}
+
+	for _, formato := range formatos {
+		t.Run(formato.nome, func(t *testing.T) {
+
+			lista, err := carregarListaTeste(t, formato.conteudo)
+			if err != nil {
+				t.Fatalf("CarregarSenhasVazadas: %s", err)
+			}
+
+			faixas := []struct {
+				prefixo string
+				sufixos []string
+			}{
+				{"5BAA6", []string{"1E4C9B93F3F0682250B6CF8331B7EE68FD8", "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF"}},
+				{"5baa6", []string{"1E4C9B93F3F0682250B6CF8331B7EE68FD8", "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF"}},
+				{"00000", []string{"00000000000000000000000000000000001"}},
+				{"FFFFF", []string{"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF"}},
+				{"00001", []string{}},
+				{"5BAA5", []string{}},
+				{"5BAA7", []string{}},
+				{"FFFFE", []string{}},
+			}
+			for _, faixa := range faixas {
+				sufixos, err := lista.Faixa(faixa.prefixo)
+				if err != nil {
+					t.Errorf("Faixa(%q): %s", faixa.prefixo, err)
+					continue
+				}
+				if !reflect.DeepEqual(sufixos, faixa.sufixos) {
+					t.Errorf("Faixa(%q) = %v, esperado %v", faixa.prefixo, sufixos, faixa.sufixos)
+				}
+			}
+
+			senhas := map[string]bool{"password": true, "uma senha que não vazou": false}
+			for senha, vazada := range senhas {
+				contem, err := lista.Contem(senha)
+				if err != nil || contem != vazada {
+					t.Errorf("Contem(%q) = (%v, %v), esperado %v", senha, contem, err, vazada)
+				}
+			}
+		})
+	}
+}
+
+func TestCarregarSenhasVazadasRecusaArquivoInvalido(t *testing.T) {
+
+	casos := []struct {
+		nome     string
+		conteudo string
+		erro     string
+	}{
+		{"fora de ordem", "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:1\n0000000000000000000000000000000000000001:1\n", "não está ordenado"},
+		{"prefixo repetido depois de outro", sha1Senha("a") + "\n" + sha1Senha("b") + "\n" + sha1Senha("a") + "\n", "não está ordenado"},
+		{"hash curto", "5BAA61E4C9B93F3F:1\n", "linha inválida"},
+		{"não hexadecimal", "ZBAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:1\n", "linha inválida"},
+		{"NTLM em vez de SHA-1", "8846F7EAEE8FB117AD06BDD830B7586C:1\n", "linha inválida"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			lista, err := carregarListaTeste(t, caso.conteudo)
+			if err == nil || !strings.Contains(err.Error(), caso.erro) {
+				t.Errorf("CarregarSenhasVazadas = (%v, %v), esperado erro com %q", lista, err, caso.erro)
+			}
+		})
+	}
+}
+
+func TestFaixaRecusaPrefixoInvalido(t *testing.T) {
+
+	lista, err := carregarListaTeste(t, sha1Senha("password")+"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, prefixo := range []string{"", "5BAA", "5BAA61", "5BAG6", "-5BAA"} {
+		if _, err := lista.Faixa(prefixo); err == nil {
+			t.Errorf("Faixa(%q) não retornou erro", prefixo)
+		}
+	}
+}
+
+func TestSemListaNenhumaSenhaEstaVazada(t *testing.T) {
+
+	var lista *ListaSenhasVazadas
+	if contem, err := lista.Contem("password"); contem || err != nil {
+		t.Errorf("Contem sem lista = (%v, %v), esperado (false, nil)", contem, err)
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// ListarSessoes lista as Sessoes ainda válidas do Usuario, das mais recentes às mais antigas,
+// marcando a Sessao atual
+func ListarSessoes(db *gorm.DB, usuarioID uint, sessaoAtualID uint) []model.Sessao {
+
+	sessoes := []model.Sessao{}
+	db.Where("usuario_id = ? AND expira_em > ?", usuarioID, time.Now()).Order("ultima_atividade DESC, id DESC").Find(&sessoes)
+
+	for i := range sessoes {
+		sessoes[i].Atual = sessoes[i].ID == sessaoAtualID
+	}
+
+	return sessoes
+}
+
+// EncerrarSessao apaga uma Sessao do Usuario, invalidando o token dela
+func EncerrarSessao(db *gorm.DB, usuarioID uint, sessaoID uint) error {
+
+	result := db.Where("id = ? AND usuario_id = ?", sessaoID, usuarioID).Delete(&model.Sessao{})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return result.Error
+}
+
+// EncerrarOutrasSessoes apaga todas as Sessoes do Usuario, menos a informada, e retorna quantas foram encerradas
+func EncerrarOutrasSessoes(db *gorm.DB, usuarioID uint, sessaoAtualID uint) (int64, error) {
+
+	result := db.Where("usuario_id = ? AND id <> ?", usuarioID, sessaoAtualID).Delete(&model.Sessao{})
+	return result.RowsAffected, result.Error
+}
+
+// EncerrarSessoes apaga todas as Sessoes do Usuario
+func EncerrarSessoes(db *gorm.DB, usuarioID uint) error {
+	return db.Where("usuario_id = ?", usuarioID).Delete(&model.Sessao{}).Error
+}
+
+// LimparSessoesExpiradas apaga as Sessoes que expiraram antes de agora
+func LimparSessoesExpiradas(db *gorm.DB, agora time.Time) (int64, error) {
+
+	result := db.Where("expira_em <= ?", agora).Delete(&model.Sessao{})
+	return result.RowsAffected, result.Error
+}
+
+// IniciarLimpezaSessoes apaga periodicamente, em segundo plano, as Sessoes expiradas
+func IniciarLimpezaSessoes(db *gorm.DB, intervalo time.Duration) {
+
+	if intervalo <= 0 {
+		return
+	}
+
+	go func() {
+		for {
+			if _, err := LimparSessoesExpiradas(db, time.Now()); err != nil {
+				log.Printf("Erro ao apagar as sessões expiradas: %s", err)
+			}
+			time.Sleep(intervalo)
+		}
+	}()
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"errors"
+	"sort"
+
+	"blogpessoal/cache"
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// NoTema é um Tema na árvore de /temas/arvore
+type NoTema struct {
+	ID               uint      `json:"id"`
+	Descricao        string    `json:"descricao"`
+	Slug             string    `json:"slug"`
+	Cor              string    `json:"cor,omitempty"`
+	Icone            string    `json:"icone,omitempty"`
+	Ordem            int       `json:"ordem"`
+	ParentID         *uint     `json:"parent_id,omitempty"`
+	TotalPostagens   int64     `json:"total_postagens"`
+	TotalComSubtemas int64     `json:"total_com_subtemas"`
+	Subtemas         []*NoTema `json:"subtemas"`
+}
+
+// hierarquiaTemas carrega os dados de exibição e o pai de todos os Temas de uma vez
+func hierarquiaTemas(db *gorm.DB) map[uint]model.Tema {
+	var temas []model.Tema
+	db.Select("id", "descricao", "slug", "cor", "icone", "ordem", "parent_id").Find(&temas)
+
+	porID := make(map[uint]model.Tema, len(temas))
+	for _, tema := range temas {
+		porID[tema.ID] = tema
+	}
+	return porID
+}
+
+// ArvoreTemas monta a árvore completa de Temas com a contagem de Postagens de cada um
+func ArvoreTemas(db *gorm.DB) []*NoTema {
+
+	var contagens []struct {
+		TemaID uint
+		Total  int64
+	}
+	db.Model(&model.Postagem{}).Select("tema_id, COUNT(*) AS total").Group("tema_id").Scan(&contagens)
+
+	totais := make(map[uint]int64, len(contagens))
+	for _, contagem := range contagens {
+		totais[contagem.TemaID] = contagem.Total
+	}
+
+	temas := hierarquiaTemas(db)
+	nos := make(map[uint]*NoTema, len(temas))
+	for id, tema := range temas {
+		nos[id] = &NoTema{
+			ID:             tema.ID,
+			Descricao:      tema.Descricao,
+			Slug:           tema.Slug,
+			Cor:            tema.Cor,
+			Icone:          tema.Icone,
+			Ordem:          tema.Ordem,
+			ParentID:       tema.ParentID,
+			TotalPostagens: totais[id],
+			Subtemas:       []*NoTema{},
+		}
+	}
+
+	var raizes []*NoTema
+	for _, no := range nos {
+		if pai, ok := nos[valorParent(no.ParentID)]; ok && no.ParentID != nil {
+			pai.Subtemas = append(pai.Subtemas, no)
+		} else {
+			raizes = append(raizes, no)
+		}
+	}
+
+	ordenarArvore(raizes)
+	for _, raiz := range raizes {
+		somarTotais(raiz)
+	}
+
+	return raizes
+}
+
+func ordenarArvore(nos []*NoTema) {
+	sort.Slice(nos, func(i, j int) bool {
+		if nos[i].Ordem != nos[j].Ordem {
+			return nos[i].Ordem < nos[j].Ordem
+		}
+		return nos[i].Descricao < nos[j].Descricao
+	})
+	for _, no := range nos {
+		ordenarArvore(no.Subtemas)
+	}
+}
+
+func somarTotais(no *NoTema) int64 {
+	no.TotalComSubtemas = no.TotalPostagens
+	for _, subtema := range no.Subtemas {
+		no.TotalComSubtemas += somarTotais(subtema)
+	}
+	return no.TotalComSubtemas
+}
+
+func valorParent(parentID *uint) uint {
+	if parentID == nil {
+		return 0
+	}
+	return *parentID
+}
+
+// CriaCiclo indica se tornar parentID o pai de temaID criaria um ciclo na hierarquia,
+// ou seja, se parentID é o próprio Tema ou um dos seus descendentes
+func CriaCiclo(db *gorm.DB, temaID uint, parentID *uint) bool {
+
+	if parentID == nil || temaID == 0 {
+		return false
+	}
+
+	temas := hierarquiaTemas(db)
+	visitados := make(map[uint]bool)
+
+	for atual := *parentID; atual != 0; atual = valorParent(temas[atual].ParentID) {
+		if atual == temaID || visitados[atual] {
+			return true
+		}
+		visitados[atual] = true
+	}
+	return false
+}
+
+// DescendentesTema retorna o id do Tema seguido dos ids de todos os seus descendentes
+func DescendentesTema(db *gorm.DB, temaID uint) []uint {
+
+	filhos := make(map[uint][]uint)
+	for id, tema := range hierarquiaTemas(db) {
+		if tema.ParentID != nil {
+			filhos[*tema.ParentID] = append(filhos[*tema.ParentID], id)
+		}
+	}
+
+	ids := []uint{temaID}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, filhos[ids[i]]...)
+	}
+	return ids
+}
+
+// PreencherBreadcrumbs preenche o caminho da raiz até o Tema de cada Postagem
+func PreencherBreadcrumbs(db *gorm.DB, postagens []model.Postagem) {
+
+	if len(postagens) == 0 {
+		return
+	}
+
+	temas := hierarquiaTemas(db)
+	caminhos := make(map[uint][]model.TemaResumo)
+
+	for i := range postagens {
+		temaID := postagens[i].TemaID
+		caminho, ok := caminhos[temaID]
+		if !ok {
+			visitados := make(map[uint]bool)
+			for atual := temaID; atual != 0 && !visitados[atual]; atual = valorParent(temas[atual].ParentID) {
+				tema, existe := temas[atual]
+				if !existe {
+					break
+				}
+				visitados[atual] = true
+				caminho = append([]model.TemaResumo{{ID: tema.ID, Descricao: tema.Descricao, Slug: tema.Slug}}, caminho...)
+			}
+			caminhos[temaID] = caminho
+		}
+		postagens[i].Breadcrumbs = caminho
+	}
+}
+
+// Erros de validação da mesclagem de Temas
+var (
+	ErrMesclagemMesmoTema   = errors.New("Tema de origem e de destino são o mesmo!")
+	ErrMesclagemDescendente = errors.New("Tema de destino não pode ser subtema do Tema de origem!")
+)
+
+// MesclarTemas move as Postagens, os subtemas e os redirecionamentos do Tema de origem
+// para o Tema de destino, registra o slug da origem como redirecionamento e remove a origem,
+// tudo em uma única transação. A origem é apagada de vez, e não enviada para a lixeira: restaurada,
+// ela voltaria vazia e com o slug que agora redireciona para o destino.
+func MesclarTemas(db *gorm.DB, origemID uint, destinoID uint) error {
+
+	if origemID == destinoID {
+		return ErrMesclagemMesmoTema
+	}
+
+	for _, id := range DescendentesTema(db, origemID) {
+		if id == destinoID {
+			return ErrMesclagemDescendente
+		}
+	}
+
+	return cache.Transaction(db, func(tx *gorm.DB) error {
+
+		var origem model.Tema
+		if err := tx.First(&origem, origemID).Error; err != nil {
+			return err
+		}
+
+		if err := moverConteudoTema(tx, origemID, destinoID); err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&model.Tema{}).Where("parent_id = ?", origemID).UpdateColumn("parent_id", destinoID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.TemaRedirecionamento{}).Where("tema_id = ?", origemID).UpdateColumn("tema_id", destinoID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("slug_antigo = ?", origem.Slug).Delete(&model.TemaRedirecionamento{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&model.TemaRedirecionamento{SlugAntigo: origem.Slug, TemaID: destinoID}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(&model.Tema{}, origemID).Error
+	})
+}
+
+// moverConteudoTema reatribui ao Tema de destino tudo que pertence ao Tema de origem,
+// preservando a data das Postagens. As Postagens na lixeira também são movidas,
+// para que possam ser restauradas depois. Os seguidores passam a seguir o destino.
+func moverConteudoTema(tx *gorm.DB, origemID uint, destinoID uint) error {
+
+	if err := tx.Unscoped().Model(&model.Postagem{}).Where("tema_id = ?", origemID).UpdateColumn("tema_id", destinoID).Error; err != nil {
+		return err
+	}
+
+	return moverSeguidoresTema(tx, origemID, destinoID)
+}
+
+// Estratégias para apagar um Tema que ainda tem Postagens
+const (
+	EstrategiaReatribuir   = "reatribuir"
+	EstrategiaSemCategoria = "sem_categoria"
+	EstrategiaCascata      = "cascata"
+)
+
+// Erros de validação da exclusão de Temas
+var (
+	ErrTemaComPostagens       = errors.New("Tema possui Postagens! Informe a estratégia: reatribuir, sem_categoria ou cascata.")
+	ErrEstrategiaInvalida     = errors.New("Estratégia Inválida!")
+	ErrDestinoInvalido        = errors.New("Tema de destino Inválido!")
+	ErrConfirmacaoObrigatoria = errors.New("A exclusão em cascata exige confirmar=true!")
+)
+
+// ExclusaoTema descreve como tratar as Postagens do Tema apagado
+type ExclusaoTema struct {
+	Estrategia string
+	DestinoID  uint
+	Confirmado bool
+}
+
+// ExcluirTema apaga o Tema em uma transação. Se houver Postagens, elas são reatribuídas
+// ao Tema de destino, movidas para o Tema "Sem Categoria" ou apagadas, conforme a estratégia.
+// Os subtemas sobem um nível na hierarquia. Na cascata, cada Postagem apagada dispara
+// postagem.removida depois que a transação é confirmada.
+func ExcluirTema(db *gorm.DB, temaID uint, exclusao ExclusaoTema) error {
+
+	var removidas []model.Postagem
+	err := cache.Transaction(db, func(tx *gorm.DB) error {
+
+		var tema model.Tema
+		if err := tx.First(&tema, temaID).Error; err != nil {
+			return err
+		}
+
+		var totalPostagens int64
+		tx.Model(&model.Postagem{}).Where("tema_id = ?", temaID).Count(&totalPostagens)
+
+		if totalPostagens > 0 {
+			switch exclusao.Estrategia {
+			case "":
+				return ErrTemaComPostagens
+
+			case EstrategiaReatribuir:
+				if exclusao.DestinoID == 0 || exclusao.DestinoID == temaID {
+					return ErrDestinoInvalido
+				}
+				var destino model.Tema
+				if tx.Find(&destino, exclusao.DestinoID); destino.ID == 0 {
+					return ErrDestinoInvalido
+				}
+				if err := moverConteudoTema(tx, temaID, destino.ID); err != nil {
+					return err
+				}
+
+			case EstrategiaSemCategoria:
+				destino, err := temaSemCategoria(tx)
+				if err != nil {
+					return err
+				}
+				if destino.ID == temaID {
+					return ErrDestinoInvalido
+				}
+				if err := moverConteudoTema(tx, temaID, destino.ID); err != nil {
+					return err
+				}
+
+			case EstrategiaCascata:
+				if !exclusao.Confirmado {
+					return ErrConfirmacaoObrigatoria
+				}
+				if err := tx.Where("tema_id = ?", temaID).Find(&removidas).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("tema_id = ?", temaID).Delete(&model.Postagem{}).Error; err != nil {
+					return err
+				}
+
+			default:
+				return ErrEstrategiaInvalida
+			}
+		}
+
+		if err := tx.Unscoped().Model(&model.Tema{}).Where("parent_id = ?", temaID).UpdateColumn("parent_id", tema.ParentID).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&model.Tema{}, temaID).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, postagem := range removidas {
+		DispararWebhookPostagem(db, EventoPostagemRemovida, postagem)
+	}
+	return nil
+}
+
+// temaSemCategoria busca o Tema "Sem Categoria", criando-o na primeira vez
+func temaSemCategoria(tx *gorm.DB) (model.Tema, error) {
+
+	var tema model.Tema
+	tx.Where("descricao_normalizada = ?", model.NormalizarTexto(model.DescricaoSemCategoria)).Find(&tema)
+	if tema.ID != 0 {
+		return tema, nil
+	}
+
+	tema = model.Tema{Descricao: model.DescricaoSemCategoria}
+	err := tx.Omit("Postagens", "Subtemas").Create(&tema).Error
+	return tema, err
+}
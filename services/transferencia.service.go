@@ -0,0 +1,381 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"blogpessoal/cache"
+	"blogpessoal/model"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// Formatos aceitos na exportação e importação de Postagens
+const (
+	FormatoNDJSON = "ndjson"
+	FormatoCSV    = "csv"
+)
+
+// PostagemTransferencia é a linha exportada/importada, com as referências
+// ao Tema e ao Usuario por id e por descrição/e-mail
+type PostagemTransferencia struct {
+	ID        uint      `json:"id"`
+	Titulo    string    `json:"titulo"`
+	Texto     string    `json:"texto"`
+	Slug      string    `json:"slug"`
+	Status    string    `json:"status"`
+	Data      time.Time `json:"data"`
+	TemaID    uint      `json:"tema_id"`
+	Tema      string    `json:"tema"`
+	UsuarioID uint      `json:"usuario_id"`
+	Usuario   string    `json:"usuario"`
+}
+
+var cabecalhoCSV = []string{"id", "titulo", "texto", "slug", "status", "data", "tema_id", "tema", "usuario_id", "usuario"}
+
+// ResultadoLinha descreve o que aconteceu com cada linha importada
+type ResultadoLinha struct {
+	Linha  int    `json:"linha"`
+	Status string `json:"status"`
+	ID     uint   `json:"id,omitempty"`
+	Erro   string `json:"erro,omitempty"`
+}
+
+type RelatorioImportacao struct {
+	Transacional bool             `json:"transacional"`
+	Aplicado     bool             `json:"aplicado"`
+	Total        int              `json:"total"`
+	Sucessos     int              `json:"sucessos"`
+	Falhas       int              `json:"falhas"`
+	Linhas       []ResultadoLinha `json:"linhas"`
+}
+
+var errImportacaoComFalhas = errors.New("importação com falhas")
+
+// ExportarPostagens escreve todas as Postagens em lotes, sem carregar a tabela inteira na memória
+func ExportarPostagens(db *gorm.DB, w io.Writer, formato string) error {
+
+	var escrever func(PostagemTransferencia) error
+	var finalizar func() error
+
+	switch formato {
+	case FormatoCSV:
+		escritor := csv.NewWriter(w)
+		if err := escritor.Write(cabecalhoCSV); err != nil {
+			return err
+		}
+		escrever = func(p PostagemTransferencia) error {
+			return escritor.Write([]string{
+				strconv.FormatUint(uint64(p.ID), 10),
+				p.Titulo,
+				p.Texto,
+				p.Slug,
+				p.Status,
+				p.Data.Format(time.RFC3339),
+				strconv.FormatUint(uint64(p.TemaID), 10),
+				p.Tema,
+				strconv.FormatUint(uint64(p.UsuarioID), 10),
+				p.Usuario,
+			})
+		}
+		finalizar = func() error {
+			escritor.Flush()
+			return escritor.Error()
+		}
+	case FormatoNDJSON:
+		encoder := json.NewEncoder(w)
+		escrever = func(p PostagemTransferencia) error {
+			return encoder.Encode(p)
+		}
+		finalizar = func() error { return nil }
+	default:
+		return fmt.Errorf("formato inválido: %s", formato)
+	}
+
+	var postagens []model.Postagem
+
+	result := db.Joins("Tema").Joins("Usuario").FindInBatches(&postagens, 500, func(tx *gorm.DB, lote int) error {
+		for _, postagem := range postagens {
+			if err := escrever(novaPostagemTransferencia(postagem)); err != nil {
+				return err
+			}
+		}
+		return finalizar()
+	})
+
+	if result.Error != nil {
+		return result.Error
+	}
+	return finalizar()
+}
+
+// ImportarPostagens lê as Postagens no formato informado e cria ou atualiza cada uma.
+// No modo transacional, qualquer falha desfaz toda a importação.
+func ImportarPostagens(db *gorm.DB, r io.Reader, formato string, transacional bool) (RelatorioImportacao, error) {
+
+	relatorio := RelatorioImportacao{Transacional: transacional}
+
+	linhas, err := lerPostagens(r, formato)
+	if err != nil {
+		return relatorio, err
+	}
+
+	importar := func(tx *gorm.DB) {
+		for _, linha := range linhas {
+			resultado := ResultadoLinha{Linha: linha.numero}
+
+			if linha.erro != nil {
+				resultado.Status = "erro"
+				resultado.Erro = linha.erro.Error()
+			} else if status, id, err := importarPostagem(tx, linha.postagem); err != nil {
+				resultado.Status = "erro"
+				resultado.Erro = err.Error()
+			} else {
+				resultado.Status = status
+				resultado.ID = id
+			}
+
+			if resultado.Erro != "" {
+				relatorio.Falhas++
+			} else {
+				relatorio.Sucessos++
+			}
+			relatorio.Linhas = append(relatorio.Linhas, resultado)
+		}
+		relatorio.Total = len(linhas)
+	}
+
+	if !transacional {
+		importar(db)
+		relatorio.Aplicado = relatorio.Sucessos > 0
+		return relatorio, nil
+	}
+
+	err = cache.Transaction(db, func(tx *gorm.DB) error {
+		importar(tx)
+		if relatorio.Falhas > 0 {
+			return errImportacaoComFalhas
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errImportacaoComFalhas) {
+		return relatorio, err
+	}
+
+	relatorio.Aplicado = err == nil
+	return relatorio, nil
+}
+
+func novaPostagemTransferencia(postagem model.Postagem) PostagemTransferencia {
+	return PostagemTransferencia{
+		ID:        postagem.ID,
+		Titulo:    postagem.Titulo,
+		Texto:     postagem.Texto,
+		Slug:      postagem.Slug,
+		Status:    postagem.Status,
+		Data:      postagem.UpdatedAt,
+		TemaID:    postagem.TemaID,
+		Tema:      postagem.Tema.Descricao,
+		UsuarioID: postagem.UsuarioID,
+		Usuario:   postagem.Usuario.Usuario,
+	}
+}
+
+type linhaImportacao struct {
+	numero   int
+	postagem PostagemTransferencia
+	erro     error
+}
+
+func lerPostagens(r io.Reader, formato string) ([]linhaImportacao, error) {
+
+	var linhas []linhaImportacao
+
+	switch formato {
+	case FormatoNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+		numero := 0
+		for scanner.Scan() {
+			numero++
+			texto := strings.TrimSpace(scanner.Text())
+			if texto == "" {
+				continue
+			}
+			linha := linhaImportacao{numero: numero}
+			linha.erro = json.Unmarshal([]byte(texto), &linha.postagem)
+			linhas = append(linhas, linha)
+		}
+		return linhas, scanner.Err()
+
+	case FormatoCSV:
+		leitor := csv.NewReader(r)
+		leitor.FieldsPerRecord = -1
+
+		cabecalho, err := leitor.Read()
+		if err != nil {
+			return nil, fmt.Errorf("cabeçalho CSV inválido: %w", err)
+		}
+		colunas := make(map[string]int)
+		for i, nome := range cabecalho {
+			colunas[strings.TrimSpace(strings.ToLower(nome))] = i
+		}
+
+		numero := 1
+		for {
+			registro, err := leitor.Read()
+			if err == io.EOF {
+				break
+			}
+			numero++
+			linha := linhaImportacao{numero: numero}
+			if err != nil {
+				linha.erro = err
+			} else {
+				linha.postagem, linha.erro = converterRegistroCSV(colunas, registro)
+			}
+			linhas = append(linhas, linha)
+		}
+		return linhas, nil
+	}
+
+	return nil, fmt.Errorf("formato inválido: %s", formato)
+}
+
+func converterRegistroCSV(colunas map[string]int, registro []string) (PostagemTransferencia, error) {
+
+	var postagem PostagemTransferencia
+
+	campo := func(nome string) string {
+		if i, ok := colunas[nome]; ok && i < len(registro) {
+			return strings.TrimSpace(registro[i])
+		}
+		return ""
+	}
+
+	numero := func(nome string) (uint, error) {
+		valor := campo(nome)
+		if valor == "" {
+			return 0, nil
+		}
+		n, err := strconv.ParseUint(valor, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("%s inválido: %s", nome, valor)
+		}
+		return uint(n), nil
+	}
+
+	var err error
+	if postagem.ID, err = numero("id"); err != nil {
+		return postagem, err
+	}
+	if postagem.TemaID, err = numero("tema_id"); err != nil {
+		return postagem, err
+	}
+	if postagem.UsuarioID, err = numero("usuario_id"); err != nil {
+		return postagem, err
+	}
+	if data := campo("data"); data != "" {
+		if postagem.Data, err = time.Parse(time.RFC3339, data); err != nil {
+			return postagem, fmt.Errorf("data inválida: %s", data)
+		}
+	}
+
+	postagem.Titulo = campo("titulo")
+	postagem.Texto = campo("texto")
+	postagem.Slug = campo("slug")
+	postagem.Status = campo("status")
+	postagem.Tema = campo("tema")
+	postagem.Usuario = campo("usuario")
+
+	return postagem, nil
+}
+
+// importarPostagem resolve as referências, valida e grava uma linha
+func importarPostagem(tx *gorm.DB, linha PostagemTransferencia) (string, uint, error) {
+
+	var tema model.Tema
+	if linha.TemaID != 0 {
+		tx.Find(&tema, linha.TemaID)
+	} else if linha.Tema != "" {
+		tx.Where("descricao_normalizada = ?", model.NormalizarTexto(linha.Tema)).Find(&tema)
+	}
+	if tema.ID == 0 {
+		return "", 0, errors.New("Tema Não Encontrado!")
+	}
+
+	var usuario model.Usuario
+	if linha.UsuarioID != 0 {
+		tx.Find(&usuario, linha.UsuarioID)
+	} else if linha.Usuario != "" {
+		tx.Where("usuario = ?", model.NormalizarEmail(linha.Usuario)).Find(&usuario)
+	}
+	if usuario.ID == 0 {
+		return "", 0, errors.New("Usuario Não Encontrado!")
+	}
+
+	postagem := model.Postagem{
+		Titulo:    linha.Titulo,
+		Texto:     linha.Texto,
+		Slug:      linha.Slug,
+		Status:    linha.Status,
+		UpdatedAt: linha.Data,
+		TemaID:    tema.ID,
+		UsuarioID: usuario.ID,
+	}
+
+	if err := validator.New().Struct(postagem); err != nil {
+		return "", 0, err
+	}
+
+	// O id só identifica a Postagem quando o slug também confere: num arquivo exportado por outra
+	// instância o mesmo id pode ser de uma Postagem sem relação nenhuma com a linha
+	status := "criada"
+	var existente model.Postagem
+	if linha.ID != 0 && linha.Slug != "" {
+		tx.Unscoped().Where("id = ? AND slug = ?", linha.ID, model.GerarSlug(linha.Slug)).Find(&existente)
+	}
+	if existente.ID == 0 && linha.Slug != "" {
+		tx.Unscoped().Where("slug = ?", model.GerarSlug(linha.Slug)).Find(&existente)
+	}
+	if existente.ID != 0 {
+		postagem.ID = existente.ID
+		status = "atualizada"
+	}
+
+	if err := salvarPostagem(tx, &postagem); err != nil {
+		return "", 0, err
+	}
+
+	return status, postagem.ID, nil
+}
+
+// salvarPostagem cria ou atualiza a Postagem preservando a data informada,
+// que o autoUpdateTime do GORM sobrescreveria na atualização. Uma Postagem
+// que estava na lixeira com o mesmo slug é atualizada e restaurada.
+func salvarPostagem(tx *gorm.DB, postagem *model.Postagem) error {
+
+	if postagem.ID == 0 {
+		return tx.Omit("Tema", "Usuario").Create(postagem).Error
+	}
+
+	data := postagem.UpdatedAt
+	if err := tx.Unscoped().Omit("Tema", "Usuario").Save(postagem).Error; err != nil {
+		return err
+	}
+	if data.IsZero() {
+		return nil
+	}
+
+	postagem.UpdatedAt = data
+	return tx.Model(postagem).UpdateColumn("data", data).Error
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"blogpessoal/mail"
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// Erros da moderação de Usuarios
+var (
+	ErrModerarASiMesmo     = errors.New("Não é possível suspender ou banir a si mesmo!")
+	ErrRemoverProprioAdmin = errors.New("Não é possível remover o próprio perfil de administrador!")
+	ErrUsuarioPendente     = errors.New("O cadastro deste Usuario aguarda aprovação! Aprove-o ou rejeite-o antes.")
+)
+
+// FiltroUsuarios são os critérios da busca de Usuarios do administrador
+type FiltroUsuarios struct {
+	Busca    string
+	Perfil   string
+	Situacao string
+	Pagina   int
+	Tamanho  int
+}
+
+// PaginaUsuarios é uma página do resultado da busca de Usuarios
+type PaginaUsuarios struct {
+	Pagina   int                  `json:"pagina"`
+	Tamanho  int                  `json:"tamanho"`
+	Total    int64                `json:"total"`
+	Usuarios []model.UsuarioAdmin `json:"usuarios"`
+}
+
+// AtividadeUsuario resume o que o Usuario fez no blog
+type AtividadeUsuario struct {
+	Usuario              model.UsuarioAdmin       `json:"usuario"`
+	PostagensPublicadas  int64                    `json:"postagens_publicadas"`
+	Rascunhos            int64                    `json:"rascunhos"`
+	PostagensNaLixeira   int64                    `json:"postagens_na_lixeira"`
+	UltimaPostagem       *time.Time               `json:"ultima_postagem"`
+	Seguidores           int64                    `json:"seguidores"`
+	Seguindo             int64                    `json:"seguindo"`
+	TemasSeguidos        int64                    `json:"temas_seguidos"`
+	NotificacoesNaoLidas int64                    `json:"notificacoes_nao_lidas"`
+	PostagensRecentes    []model.PostagemResposta `json:"postagens_recentes"`
+}
+
+// BuscarUsuarios lista os Usuarios pelo nome, e-mail ou slug, filtrando pelo perfil e pela situação da conta
+func BuscarUsuarios(db *gorm.DB, filtro FiltroUsuarios) PaginaUsuarios {
+
+	resultado := PaginaUsuarios{Pagina: filtro.Pagina, Tamanho: filtro.Tamanho, Usuarios: []model.UsuarioAdmin{}}
+	agora := time.Now()
+
+	consulta := db.Model(&model.Usuario{})
+	if filtro.Busca != "" {
+		busca := "%" + filtro.Busca + "%"
+		consulta = consulta.Where("nome LIKE ? OR usuario LIKE ? OR slug LIKE ?", busca, busca, busca)
+	}
+	if filtro.Perfil != "" {
+		consulta = consulta.Where("perfil = ?", filtro.Perfil)
+	}
+
+	// As suspensões que já terminaram contam como contas ativas
+	switch filtro.Situacao {
+	case model.SituacaoAtivo:
+		consulta = consulta.Where("situacao = ? OR (situacao = ? AND suspenso_ate <= ?)", model.SituacaoAtivo, model.SituacaoSuspenso, agora)
+	case model.SituacaoSuspenso:
+		consulta = consulta.Where("situacao = ? AND (suspenso_ate IS NULL OR suspenso_ate > ?)", model.SituacaoSuspenso, agora)
+	case model.SituacaoBanido, model.SituacaoPendente:
+		consulta = consulta.Where("situacao = ?", filtro.Situacao)
+	}
+	consulta = consulta.Session(&gorm.Session{})
+
+	var usuarios []model.Usuario
+	consulta.Count(&resultado.Total)
+	consulta.Order("nome, id").Offset((filtro.Pagina - 1) * filtro.Tamanho).Limit(filtro.Tamanho).Find(&usuarios)
+
+	if len(usuarios) == 0 {
+		return resultado
+	}
+
+	ids := make([]uint, len(usuarios))
+	for i, usuario := range usuarios {
+		ids[i] = usuario.ID
+	}
+
+	var totais []struct {
+		UsuarioID uint
+		Total     int64
+	}
+	db.Model(&model.Postagem{}).Select("usuario_id, COUNT(*) AS total").Where("usuario_id IN ?", ids).Group("usuario_id").Scan(&totais)
+
+	postagensPorUsuario := make(map[uint]int64, len(totais))
+	for _, total := range totais {
+		postagensPorUsuario[total.UsuarioID] = total.Total
+	}
+
+	for _, usuario := range usuarios {
+		admin := usuario.Admin()
+		admin.TotalPostagens = postagensPorUsuario[usuario.ID]
+		resultado.Usuarios = append(resultado.Usuarios, admin)
+	}
+
+	return resultado
+}
+
+// AtividadeDoUsuario reúne os números e as Postagens mais recentes do Usuario
+func AtividadeDoUsuario(db *gorm.DB, usuario model.Usuario) AtividadeUsuario {
+
+	atividade := AtividadeUsuario{Usuario: usuario.Admin()}
+
+	db.Model(&model.Postagem{}).Where("usuario_id = ? AND status = ?", usuario.ID, model.StatusPublicado).Count(&atividade.PostagensPublicadas)
+	db.Model(&model.Postagem{}).Where("usuario_id = ? AND status = ?", usuario.ID, model.StatusRascunho).Count(&atividade.Rascunhos)
+	db.Unscoped().Model(&model.Postagem{}).Where("usuario_id = ? AND deleted_at IS NOT NULL", usuario.ID).Count(&atividade.PostagensNaLixeira)
+	db.Model(&model.SeguidorUsuario{}).Where("usuario_id = ?", usuario.ID).Count(&atividade.Seguidores)
+	db.Model(&model.SeguidorUsuario{}).Where("seguidor_id = ?", usuario.ID).Count(&atividade.Seguindo)
+	db.Model(&model.SeguidorTema{}).Where("usuario_id = ?", usuario.ID).Count(&atividade.TemasSeguidos)
+	atividade.NotificacoesNaoLidas = TotalNaoLidas(db, usuario.ID)
+
+	var ultima struct{ Data *time.Time }
+	db.Model(&model.Postagem{}).Select("MAX(data) AS data").Where("usuario_id = ?", usuario.ID).Scan(&ultima)
+	atividade.UltimaPostagem = ultima.Data
+
+	atividade.Usuario.TotalPostagens = atividade.PostagensPublicadas + atividade.Rascunhos
+
+	var recentes []model.Postagem
+	db.Joins("Tema").Where(model.Postagem{}.TableName()+".usuario_id = ?", usuario.ID).Order("data DESC").Limit(10).Find(&recentes)
+	atividade.PostagensRecentes = model.PostagensResposta(recentes)
+
+	return atividade
+}
+
+// SuspenderUsuario bloqueia o acesso do Usuario até a data informada ou, sem data, até ele ser reativado.
+// Banir é uma suspensão sem término com a situação banido. Os cadastros pendentes não podem ser moderados.
+func SuspenderUsuario(db *gorm.DB, usuarioID uint, situacao string, motivo string, ate *time.Time) error {
+
+	if situacao == model.SituacaoBanido {
+		ate = nil
+	}
+
+	return alterarSituacaoUsuario(db, usuarioID, map[string]interface{}{
+		"situacao":         situacao,
+		"motivo_suspensao": motivo,
+		"suspenso_ate":     ate,
+	})
+}
+
+// ReativarUsuario encerra a suspensão ou o banimento do Usuario. Reativar não aprova um cadastro pendente.
+func ReativarUsuario(db *gorm.DB, usuarioID uint) error {
+
+	return alterarSituacaoUsuario(db, usuarioID, map[string]interface{}{
+		"situacao":         model.SituacaoAtivo,
+		"motivo_suspensao": "",
+		"suspenso_ate":     nil,
+	})
+}
+
+// alterarSituacaoUsuario altera a situação do Usuario, exceto a dos cadastros pendentes, que só
+// saem dessa situação pela aprovação ou rejeição
+func alterarSituacaoUsuario(db *gorm.DB, usuarioID uint, colunas map[string]interface{}) error {
+
+	var pendentes int64
+	if db.Model(&model.Usuario{}).Where("id = ? AND situacao = ?", usuarioID, model.SituacaoPendente).Count(&pendentes); pendentes > 0 {
+		return ErrUsuarioPendente
+	}
+
+	return db.Model(&model.Usuario{}).Where("id = ? AND situacao <> ?", usuarioID, model.SituacaoPendente).UpdateColumns(colunas).Error
+}
+
+// AlterarPerfilUsuario troca o perfil de acesso do Usuario
+func AlterarPerfilUsuario(db *gorm.DB, usuarioID uint, perfil string) error {
+	return db.Model(&model.Usuario{}).Where("id = ?", usuarioID).UpdateColumn("perfil", perfil).Error
+}
+
+// UsuariosVisiveis é o escopo das consultas que listam Usuarios para os outros Usuarios ou
+// nas páginas públicas, sem as contas banidas e as que aguardam aprovação (ver model.Usuario.Visivel)
+func UsuariosVisiveis(db *gorm.DB) *gorm.DB {
+	return db.Where(model.Usuario{}.TableName()+".situacao NOT IN ?", []string{model.SituacaoBanido, model.SituacaoPendente})
+}
+
+// ForcarRedefinicaoSenha bloqueia a senha atual do Usuario, encerra as Sessoes dele e envia por e-mail
+// um link de uso único para escolher uma nova. A senha antiga não serve mais para nada, o que
+// protege as contas comprometidas, cuja senha quem as invadiu conhece.
+func ForcarRedefinicaoSenha(db *gorm.DB, mailer mail.Mailer, usuarioID uint) error {
+
+	token, hash, err := gerarTokenRedefinicao()
+	if err != nil {
+		return err
+	}
+	expiraEm := time.Now().Add(RedefinicaoSenha.Validade)
+
+	err = db.Model(&model.Usuario{}).Where("id = ?", usuarioID).UpdateColumns(map[string]interface{}{
+		"senha":                       SenhaBloqueada,
+		"redefinir_senha":             true,
+		"token_redefinicao_senha":     hash,
+		"redefinicao_senha_expira_em": expiraEm,
+		"versao_token":                gorm.Expr("versao_token + 1"),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	if err := EncerrarSessoes(db, usuarioID); err != nil {
+		return err
+	}
+
+	var usuario model.Usuario
+	if err := db.Select("id", "nome", "usuario").First(&usuario, usuarioID).Error; err != nil {
+		return err
+	}
+	if err := enviarRedefinicaoSenha(mailer, usuario, token, expiraEm); err != nil {
+		return fmt.Errorf("%w: %s", ErrEnvioRedefinicaoSenha, err)
+	}
+	return nil
+}
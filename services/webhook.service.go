@@ -0,0 +1,313 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+// Eventos de conteúdo enviados aos Webhooks
+const (
+	EventoPostagemCriada     = "postagem.criada"
+	EventoPostagemAtualizada = "postagem.atualizada"
+	EventoPostagemRemovida   = "postagem.removida"
+	EventoTemaCriado         = "tema.criado"
+	EventoTemaAtualizado     = "tema.atualizado"
+	EventoTemaRemovido       = "tema.removido"
+	EventoTemaMesclado       = "tema.mesclado"
+	EventoUsuarioCriado      = "usuario.criado"
+	EventoUsuarioRemovido    = "usuario.removido"
+)
+
+// EventosWebhook lista os eventos que podem ser assinados
+var EventosWebhook = []string{
+	EventoPostagemCriada, EventoPostagemAtualizada, EventoPostagemRemovida,
+	EventoTemaCriado, EventoTemaAtualizado, EventoTemaRemovido, EventoTemaMesclado,
+	EventoUsuarioCriado, EventoUsuarioRemovido,
+}
+
+const (
+	// Tentativas de cada entrega antes de desistir
+	maxTentativasWebhook = 6
+	// Espera antes da segunda tentativa; dobra a cada nova falha
+	esperaInicialWebhook = 30 * time.Second
+	// Falhas seguidas, em qualquer entrega, que desativam o Webhook
+	limiteFalhasWebhook = 20
+	// Tamanho máximo da resposta guardada no registro de entregas
+	tamanhoRespostaWebhook = 1000
+)
+
+var clienteWebhook = &http.Client{Timeout: 10 * time.Second}
+
+// entregasPendentes acorda o entregador quando um evento é disparado
+var entregasPendentes = make(chan struct{}, 1)
+
+// PayloadWebhook é o corpo JSON enviado aos Webhooks
+type PayloadWebhook struct {
+	Evento     string      `json:"evento"`
+	OcorridoEm time.Time   `json:"ocorrido_em"`
+	Dados      interface{} `json:"dados"`
+}
+
+// EventoValido indica se o padrão é um evento conhecido, "*" ou um grupo como "tema.*"
+func EventoValido(padrao string) bool {
+	for _, evento := range EventosWebhook {
+		if eventoCorresponde(padrao, evento) {
+			return true
+		}
+	}
+	return false
+}
+
+func eventoCorresponde(padrao string, evento string) bool {
+	if padrao == "*" || padrao == evento {
+		return true
+	}
+	return strings.HasSuffix(padrao, ".*") && strings.HasPrefix(evento, strings.TrimSuffix(padrao, "*"))
+}
+
+// GerarSegredoWebhook cria um segredo aleatório para assinar os payloads
+func GerarSegredoWebhook() string {
+	segredo := make([]byte, 32)
+	rand.Read(segredo)
+	return hex.EncodeToString(segredo)
+}
+
+// AssinaturaWebhook calcula o HMAC-SHA256 de "<timestamp>.<corpo>" com o segredo do Webhook,
+// no formato enviado no cabeçalho X-Webhook-Assinatura
+func AssinaturaWebhook(segredo string, timestamp string, corpo []byte) string {
+	mac := hmac.New(sha256.New, []byte(segredo))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(corpo)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// DispararWebhook registra uma entrega do evento para cada Webhook ativo que o assina.
+// As entregas são feitas em segundo plano pelo entregador iniciado com IniciarEntregaWebhooks.
+func DispararWebhook(db *gorm.DB, evento string, dados interface{}) {
+
+	var webhooks []model.Webhook
+	db.Where("ativo = ?", true).Find(&webhooks)
+
+	payload, err := json.Marshal(PayloadWebhook{Evento: evento, OcorridoEm: time.Now(), Dados: dados})
+	if err != nil {
+		log.Printf("Erro ao gerar o payload do evento %s: %s", evento, err)
+		return
+	}
+
+	agora := time.Now()
+	var entregas []model.EntregaWebhook
+	for _, webhook := range webhooks {
+		for _, padrao := range webhook.Eventos {
+			if eventoCorresponde(padrao, evento) {
+				entregas = append(entregas, model.EntregaWebhook{
+					WebhookID:        webhook.ID,
+					Evento:           evento,
+					Payload:          string(payload),
+					Status:           model.EntregaPendente,
+					ProximaTentativa: &agora,
+				})
+				break
+			}
+		}
+	}
+
+	if len(entregas) == 0 {
+		return
+	}
+
+	if err := db.Omit("Webhook").Create(&entregas).Error; err != nil {
+		log.Printf("Erro ao registrar as entregas do evento %s: %s", evento, err)
+		return
+	}
+	acordarEntregador()
+}
+
+// ReenviarEntrega registra uma nova entrega com o mesmo payload de uma entrega anterior
+func ReenviarEntrega(db *gorm.DB, entregaID uint) (model.EntregaWebhook, error) {
+
+	var original model.EntregaWebhook
+	if err := db.First(&original, entregaID).Error; err != nil {
+		return original, err
+	}
+
+	agora := time.Now()
+	entrega := model.EntregaWebhook{
+		WebhookID:        original.WebhookID,
+		Evento:           original.Evento,
+		Payload:          original.Payload,
+		Status:           model.EntregaPendente,
+		ProximaTentativa: &agora,
+	}
+	if err := db.Omit("Webhook").Create(&entrega).Error; err != nil {
+		return entrega, err
+	}
+
+	acordarEntregador()
+	return entrega, nil
+}
+
+func acordarEntregador() {
+	select {
+	case entregasPendentes <- struct{}{}:
+	default:
+	}
+}
+
+// IniciarEntregaWebhooks entrega, em segundo plano, as entregas pendentes cuja hora chegou,
+// verificando a cada intervalo ou assim que um evento é disparado
+func IniciarEntregaWebhooks(db *gorm.DB, intervalo time.Duration) {
+
+	go func() {
+		for {
+			EntregarPendentes(db, time.Now())
+			select {
+			case <-entregasPendentes:
+			case <-time.After(intervalo):
+			}
+		}
+	}()
+}
+
+// EntregarPendentes faz uma tentativa de cada entrega pendente cuja hora chegou
+func EntregarPendentes(db *gorm.DB, agora time.Time) {
+
+	var entregas []model.EntregaWebhook
+	db.Joins("Webhook").
+		Where(model.EntregaWebhook{}.TableName()+".status = ? AND proxima_tentativa <= ?", model.EntregaPendente, agora).
+		Order(model.EntregaWebhook{}.TableName() + ".id").
+		Limit(100).
+		Find(&entregas)
+
+	for _, entrega := range entregas {
+		entregar(db, entrega)
+	}
+}
+
+func entregar(db *gorm.DB, entrega model.EntregaWebhook) {
+
+	webhook := entrega.Webhook
+
+	if !webhook.Ativo {
+		db.Model(&entrega).Updates(map[string]interface{}{"status": model.EntregaFalha, "erro": "Webhook desativado", "proxima_tentativa": nil})
+		return
+	}
+
+	statusHTTP, resposta, err := enviarWebhook(webhook, entrega)
+
+	entrega.Tentativas++
+	entrega.StatusHTTP = statusHTTP
+	entrega.Resposta = limitar(resposta, tamanhoRespostaWebhook)
+	entrega.Erro = ""
+	entrega.ProximaTentativa = nil
+
+	if err == nil {
+		entrega.Status = model.EntregaSucesso
+		db.Model(&model.Webhook{}).Where("id = ?", webhook.ID).UpdateColumn("falhas_consecutivas", 0)
+	} else {
+		entrega.Erro = limitar(err.Error(), 500)
+		entrega.Status = model.EntregaFalha
+		if entrega.Tentativas < maxTentativasWebhook {
+			entrega.Status = model.EntregaPendente
+			proxima := time.Now().Add(esperaInicialWebhook << (entrega.Tentativas - 1))
+			entrega.ProximaTentativa = &proxima
+		}
+		registrarFalhaWebhook(db, webhook)
+	}
+
+	db.Model(&entrega).Select("tentativas", "status_http", "resposta", "erro", "status", "proxima_tentativa", "updated_at").Updates(&entrega)
+}
+
+// registrarFalhaWebhook conta a falha e desativa o Webhook que atingiu o limite de falhas seguidas
+func registrarFalhaWebhook(db *gorm.DB, webhook model.Webhook) {
+
+	db.Model(&model.Webhook{}).Where("id = ?", webhook.ID).UpdateColumn("falhas_consecutivas", gorm.Expr("falhas_consecutivas + 1"))
+
+	result := db.Model(&model.Webhook{}).
+		Where("id = ? AND ativo = ? AND falhas_consecutivas >= ?", webhook.ID, true, limiteFalhasWebhook).
+		Updates(map[string]interface{}{"ativo": false, "desativado_em": time.Now()})
+	if result.RowsAffected > 0 {
+		log.Printf("Webhook %d desativado após %d falhas seguidas", webhook.ID, limiteFalhasWebhook)
+	}
+}
+
+func enviarWebhook(webhook model.Webhook, entrega model.EntregaWebhook) (int, string, error) {
+
+	corpo := []byte(entrega.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	requisicao, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(corpo))
+	if err != nil {
+		return 0, "", err
+	}
+	requisicao.Header.Set("Content-Type", "application/json")
+	requisicao.Header.Set("User-Agent", "BlogPessoal-Webhook/1.0")
+	requisicao.Header.Set("X-Webhook-Evento", entrega.Evento)
+	requisicao.Header.Set("X-Webhook-Entrega", strconv.FormatUint(uint64(entrega.ID), 10))
+	requisicao.Header.Set("X-Webhook-Timestamp", timestamp)
+	requisicao.Header.Set("X-Webhook-Assinatura", AssinaturaWebhook(webhook.Segredo, timestamp, corpo))
+
+	resposta, err := clienteWebhook.Do(requisicao)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resposta.Body.Close()
+
+	conteudo, _ := io.ReadAll(io.LimitReader(resposta.Body, tamanhoRespostaWebhook))
+
+	if resposta.StatusCode < 200 || resposta.StatusCode > 299 {
+		return resposta.StatusCode, string(conteudo), fmt.Errorf("resposta HTTP %d", resposta.StatusCode)
+	}
+	return resposta.StatusCode, string(conteudo), nil
+}
+
+// limitar corta o texto no tamanho máximo da coluna sem deixar caracteres UTF-8 pela metade
+func limitar(texto string, tamanho int) string {
+	if len(texto) > tamanho {
+		texto = texto[:tamanho]
+	}
+	return strings.ToValidUTF8(texto, "")
+}
+
+// DispararWebhookPostagem dispara o evento com os dados da Postagem, sem as associações
+func DispararWebhookPostagem(db *gorm.DB, evento string, postagem model.Postagem) {
+	DispararWebhook(db, evento, map[string]interface{}{
+		"id":         postagem.ID,
+		"titulo":     postagem.Titulo,
+		"texto":      postagem.Texto,
+		"slug":       postagem.Slug,
+		"status":     postagem.Status,
+		"data":       postagem.UpdatedAt,
+		"tema_id":    postagem.TemaID,
+		"usuario_id": postagem.UsuarioID,
+	})
+}
+
+// DispararWebhookTema dispara o evento com os dados do Tema, sem as associações
+func DispararWebhookTema(db *gorm.DB, evento string, tema model.Tema) {
+	DispararWebhook(db, evento, map[string]interface{}{
+		"id":        tema.ID,
+		"descricao": tema.Descricao,
+		"slug":      tema.Slug,
+		"parent_id": tema.ParentID,
+	})
+}
+
+// DispararWebhookUsuario dispara o evento apenas com os dados públicos do Usuario
+func DispararWebhookUsuario(db *gorm.DB, evento string, usuario model.Usuario) {
+	DispararWebhook(db, evento, usuario.Resumo())
+}
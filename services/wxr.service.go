@@ -0,0 +1,349 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"blogpessoal/cache"
+	"blogpessoal/model"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// Senha gravada nos usuarios importados: não corresponde a nenhum hash bcrypt ou argon2id,
+// então o login fica bloqueado até que uma nova senha seja definida
+const SenhaBloqueada = "!"
+
+// Estrutura mínima de um arquivo WXR (WordPress eXtended RSS).
+// As tags sem namespace casam com os elementos wp:, dc: e content: de qualquer versão do WXR.
+type wxrArquivo struct {
+	Channel struct {
+		Autores    []wxrAutor     `xml:"author"`
+		Categorias []wxrCategoria `xml:"category"`
+		Itens      []wxrItem      `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrAutor struct {
+	Login string `xml:"author_login"`
+	Email string `xml:"author_email"`
+	Nome  string `xml:"author_display_name"`
+}
+
+type wxrCategoria struct {
+	Slug string `xml:"category_nicename"`
+	Nome string `xml:"cat_name"`
+}
+
+type wxrItem struct {
+	Titulo     string `xml:"title"`
+	Autor      string `xml:"creator"`
+	Conteudo   string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PostID     string `xml:"post_id"`
+	GUID       string `xml:"guid"`
+	Data       string `xml:"post_date"`
+	DataGMT    string `xml:"post_date_gmt"`
+	Slug       string `xml:"post_name"`
+	Status     string `xml:"status"`
+	Tipo       string `xml:"post_type"`
+	Categorias []struct {
+		Dominio string `xml:"domain,attr"`
+		Slug    string `xml:"nicename,attr"`
+		Nome    string `xml:",chardata"`
+	} `xml:"category"`
+}
+
+// RelatorioWXR resume uma importação do WordPress
+type RelatorioWXR struct {
+	DryRun           bool             `json:"dry_run"`
+	UsuariosCriados  int              `json:"usuarios_criados"`
+	TemasCriados     int              `json:"temas_criados"`
+	PostagensCriadas int              `json:"postagens_criadas"`
+	PostagensAtualiz int              `json:"postagens_atualizadas"`
+	ItensIgnorados   int              `json:"itens_ignorados"`
+	Falhas           int              `json:"falhas"`
+	Itens            []ResultadoLinha `json:"itens"`
+}
+
+var errDryRun = errors.New("dry-run")
+
+var errAutorWXRNaLixeira = errors.New("está na lixeira; restaure-o antes de importar as postagens dele")
+
+// ImportarWXR cria os Usuarios, Temas (a partir das categorias) e Postagens de uma exportação do WordPress.
+// Usuarios são identificados pelo e-mail, Temas pelo slug e Postagens pelo guid (ou post_id) do item,
+// gravado em OrigemWXR, então importar o mesmo arquivo novamente atualiza os registros em vez de
+// duplicá-los. O slug nunca identifica uma Postagem: se ele já pertence a outra, a importada recebe
+// um sufixo. Itens já importados que estão na lixeira são ignorados, e os itens de um autor cujo
+// Usuario está na lixeira falham individualmente. No dry-run, tudo é desfeito no final.
+func ImportarWXR(db *gorm.DB, r io.Reader, dryRun bool) (RelatorioWXR, error) {
+
+	relatorio := RelatorioWXR{DryRun: dryRun}
+
+	var arquivo wxrArquivo
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	if err := decoder.Decode(&arquivo); err != nil {
+		return relatorio, fmt.Errorf("arquivo WXR inválido: %w", err)
+	}
+
+	err := cache.Transaction(db, func(tx *gorm.DB) error {
+
+		autores := make(map[string]uint)
+		autoresRecusados := make(map[string]error)
+		for _, autor := range arquivo.Channel.Autores {
+			id, criado, err := importarAutorWXR(tx, autor)
+			if errors.Is(err, errAutorWXRNaLixeira) {
+				autoresRecusados[autor.Login] = err
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("autor %s: %w", autor.Login, err)
+			}
+			if criado {
+				relatorio.UsuariosCriados++
+			}
+			autores[autor.Login] = id
+		}
+
+		temas := make(map[string]uint)
+		for _, categoria := range arquivo.Channel.Categorias {
+			id, criado, err := importarCategoriaWXR(tx, categoria.Slug, categoria.Nome)
+			if err != nil {
+				return fmt.Errorf("categoria %s: %w", categoria.Slug, err)
+			}
+			if criado {
+				relatorio.TemasCriados++
+			}
+			temas[categoria.Slug] = id
+		}
+
+		for i, item := range arquivo.Channel.Itens {
+			resultado := ResultadoLinha{Linha: i + 1}
+
+			if item.Tipo != "" && item.Tipo != "post" || item.Status == "trash" || item.Status == "auto-draft" {
+				relatorio.ItensIgnorados++
+				continue
+			}
+
+			var status string
+			var id uint
+			err, recusado := autoresRecusados[item.Autor]
+			if !recusado {
+				status, id, err = importarItemWXR(tx, item, autores, temas, &relatorio)
+			}
+			if err != nil {
+				resultado.Status = "erro"
+				resultado.Erro = fmt.Sprintf("%s: %s", item.Titulo, err.Error())
+				relatorio.Falhas++
+			} else {
+				resultado.Status = status
+				resultado.ID = id
+				switch status {
+				case "criada":
+					relatorio.PostagensCriadas++
+				case "atualizada":
+					relatorio.PostagensAtualiz++
+				default:
+					relatorio.ItensIgnorados++
+				}
+			}
+			relatorio.Itens = append(relatorio.Itens, resultado)
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errDryRun) {
+		return relatorio, err
+	}
+
+	return relatorio, nil
+}
+
+func importarAutorWXR(tx *gorm.DB, autor wxrAutor) (uint, bool, error) {
+
+	email := model.NormalizarEmail(autor.Email)
+	if email == "" {
+		email = model.NormalizarEmail(autor.Login + "@wordpress.importado")
+	}
+
+	// O e-mail continua reservado pelos Usuarios na lixeira, então criar outro falharia
+	var usuario model.Usuario
+	tx.Unscoped().Where("usuario = ?", email).Find(&usuario)
+	if usuario.DeletedAt.Valid {
+		return 0, false, fmt.Errorf("usuario %s %w", email, errAutorWXRNaLixeira)
+	}
+	if usuario.ID != 0 {
+		return usuario.ID, false, nil
+	}
+
+	usuario = model.Usuario{
+		Nome:    strings.TrimSpace(autor.Nome),
+		Usuario: email,
+		Senha:   SenhaBloqueada,
+		Perfil:  model.PerfilUsuario,
+	}
+	if usuario.Nome == "" {
+		usuario.Nome = autor.Login
+	}
+
+	if err := tx.Omit("Postagens").Create(&usuario).Error; err != nil {
+		return 0, false, err
+	}
+	return usuario.ID, true, nil
+}
+
+func importarCategoriaWXR(tx *gorm.DB, slug string, nome string) (uint, bool, error) {
+
+	slug = slugWXR(slug)
+	if slug == "" {
+		slug = model.GerarSlug(nome)
+	}
+
+	var tema model.Tema
+	tx.Where("slug = ?", slug).Find(&tema)
+	if tema.ID == 0 && nome != "" {
+		tx.Where("descricao_normalizada = ?", model.NormalizarTexto(nome)).Find(&tema)
+	}
+	if tema.ID != 0 {
+		return tema.ID, false, nil
+	}
+
+	tema = model.Tema{Descricao: strings.TrimSpace(nome), Slug: slug}
+	if tema.Descricao == "" {
+		tema.Descricao = slug
+	}
+
+	if err := tx.Omit("Postagens").Create(&tema).Error; err != nil {
+		return 0, false, err
+	}
+	return tema.ID, true, nil
+}
+
+func importarItemWXR(tx *gorm.DB, item wxrItem, autores map[string]uint, temas map[string]uint, relatorio *RelatorioWXR) (string, uint, error) {
+
+	usuarioID, ok := autores[item.Autor]
+	if !ok {
+		return "", 0, fmt.Errorf("autor %q não declarado no arquivo", item.Autor)
+	}
+
+	var temaID uint
+	for _, categoria := range item.Categorias {
+		if categoria.Dominio != "category" {
+			continue
+		}
+		id, ok := temas[categoria.Slug]
+		if !ok {
+			novoID, criado, err := importarCategoriaWXR(tx, categoria.Slug, categoria.Nome)
+			if err != nil {
+				return "", 0, err
+			}
+			if criado {
+				relatorio.TemasCriados++
+			}
+			temas[categoria.Slug] = novoID
+			id = novoID
+		}
+		temaID = id
+		break
+	}
+	if temaID == 0 {
+		return "", 0, errors.New("postagem sem categoria")
+	}
+
+	postagem := model.Postagem{
+		Titulo:    strings.TrimSpace(item.Titulo),
+		Texto:     strings.TrimSpace(item.Conteudo),
+		Slug:      slugWXR(item.Slug),
+		Status:    statusWXR(item.Status),
+		UpdatedAt: dataWXR(item),
+		TemaID:    temaID,
+		UsuarioID: usuarioID,
+	}
+	if origem := origemWXR(item); origem != "" {
+		postagem.OrigemWXR = &origem
+	}
+
+	if err := validator.New().Struct(postagem); err != nil {
+		return "", 0, err
+	}
+
+	status := "criada"
+	var existente model.Postagem
+	if postagem.OrigemWXR != nil {
+		tx.Unscoped().Where("origem_wxr = ?", *postagem.OrigemWXR).Find(&existente)
+	}
+
+	// Uma Postagem importada antes e enviada para a lixeira não é restaurada pela reimportação
+	if existente.DeletedAt.Valid {
+		return "ignorada", existente.ID, nil
+	}
+	if existente.ID != 0 {
+		postagem.ID = existente.ID
+		status = "atualizada"
+	}
+
+	if err := salvarPostagem(tx, &postagem); err != nil {
+		return "", 0, err
+	}
+
+	return status, postagem.ID, nil
+}
+
+// slugWXR normaliza os slugs do WordPress, que chegam codificados para URL quando têm acentos
+func slugWXR(slug string) string {
+	if decodificado, err := url.PathUnescape(slug); err == nil {
+		slug = decodificado
+	}
+	return model.GerarSlug(slug)
+}
+
+// origemWXR identifica o item do WordPress: o guid, que inclui o endereço do site de origem, ou,
+// na falta dele, o post_id. Guids longos demais para a coluna são gravados pelo hash.
+func origemWXR(item wxrItem) string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		if len(guid) <= 255 {
+			return guid
+		}
+		soma := sha1.Sum([]byte(guid))
+		return "guid-sha1:" + hex.EncodeToString(soma[:])
+	}
+
+	if id := strings.TrimSpace(item.PostID); id != "" {
+		return "post_id:" + id
+	}
+	return ""
+}
+
+// statusWXR converte a situação do WordPress: só "publish" vira uma Postagem publicada, e os
+// rascunhos, pendentes e privados viram rascunhos, visíveis apenas ao autor e aos administradores.
+// Os itens "trash" e "auto-draft" nem chegam aqui: ImportarWXR os ignora.
+func statusWXR(status string) string {
+	if status == "publish" {
+		return model.StatusPublicado
+	}
+	return model.StatusRascunho
+}
+
+func dataWXR(item wxrItem) time.Time {
+	const layout = "2006-01-02 15:04:05"
+
+	if data, err := time.ParseInLocation(layout, item.DataGMT, time.UTC); err == nil && !data.IsZero() {
+		return data
+	}
+	if data, err := time.ParseInLocation(layout, item.Data, time.Local); err == nil {
+		return data
+	}
+	return time.Now()
+}
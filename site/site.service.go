@@ -0,0 +1,293 @@
+package site
+
+import (
+	"bytes"
+	"embed"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"blogpessoal/model"
+
+	"gorm.io/gorm"
+)
+
+//go:embed layouts
+var layouts embed.FS
+
+// Opcoes da geração do site estático
+type Opcoes struct {
+	Saida     string
+	Layout    string
+	Titulo    string
+	URL       string
+	PorPagina int
+}
+
+// Dados comuns a todas as páginas
+type dadosSite struct {
+	Titulo   string
+	Raiz     string
+	Temas    []model.Tema
+	GeradoEm time.Time
+}
+
+type gerador struct {
+	opcoes  Opcoes
+	site    dadosSite
+	paginas map[string]*template.Template
+	total   int
+}
+
+// Layouts disponíveis, um por pasta em site/layouts
+func Layouts() []string {
+	var nomes []string
+	entradas, _ := layouts.ReadDir("layouts")
+	for _, entrada := range entradas {
+		if entrada.IsDir() {
+			nomes = append(nomes, entrada.Name())
+		}
+	}
+	return nomes
+}
+
+// Gerar renderiza as Postagens publicadas em arquivos HTML e feeds RSS prontos para
+// qualquer servidor de arquivos: índice paginado, postagens, temas e autores.
+// Retorna a quantidade de arquivos gravados.
+func Gerar(db *gorm.DB, opcoes Opcoes) (int, error) {
+
+	if opcoes.PorPagina <= 0 {
+		opcoes.PorPagina = 10
+	}
+
+	g := &gerador{
+		opcoes:  opcoes,
+		paginas: make(map[string]*template.Template),
+		site: dadosSite{
+			Titulo:   opcoes.Titulo,
+			Raiz:     strings.TrimRight(opcoes.URL, "/"),
+			GeradoEm: time.Now(),
+		},
+	}
+
+	if err := g.carregarTemplates(); err != nil {
+		return 0, err
+	}
+
+	var postagens []model.Postagem
+	db.Joins("Tema").Joins("Usuario").Where("status = ?", model.StatusPublicado).Order("data DESC").Find(&postagens)
+	db.Order("ordem, descricao").Find(&g.site.Temas)
+
+	if err := g.gerarIndice(postagens); err != nil {
+		return g.total, err
+	}
+
+	porTema := make(map[uint][]model.Postagem)
+	porAutor := make(map[uint][]model.Postagem)
+	autores := make(map[uint]model.Usuario)
+
+	for _, postagem := range postagens {
+		if err := g.renderizar(path.Join("postagens", postagem.Slug, "index.html"), "postagem", map[string]interface{}{
+			"Site":     g.site,
+			"Postagem": postagem,
+		}); err != nil {
+			return g.total, err
+		}
+		porTema[postagem.TemaID] = append(porTema[postagem.TemaID], postagem)
+
+		// Contas banidas ou pendentes não ganham página de autor, como em GET /autores/{slug}
+		if postagem.Usuario.Visivel() {
+			porAutor[postagem.UsuarioID] = append(porAutor[postagem.UsuarioID], postagem)
+			autores[postagem.UsuarioID] = postagem.Usuario
+		}
+	}
+
+	for _, tema := range g.site.Temas {
+		if err := g.renderizar(path.Join("temas", tema.Slug, "index.html"), "tema", map[string]interface{}{
+			"Site":      g.site,
+			"Tema":      tema,
+			"Postagens": porTema[tema.ID],
+		}); err != nil {
+			return g.total, err
+		}
+		if err := g.gerarFeed(path.Join("temas", tema.Slug, "feed.xml"), g.site.Titulo+" - "+tema.Descricao, porTema[tema.ID]); err != nil {
+			return g.total, err
+		}
+	}
+
+	for id, autor := range autores {
+		if err := g.renderizar(path.Join("autores", autor.Slug, "index.html"), "autor", map[string]interface{}{
+			"Site":      g.site,
+			"Autor":     autor,
+			"Postagens": porAutor[id],
+		}); err != nil {
+			return g.total, err
+		}
+	}
+
+	if err := g.gerarFeed("feed.xml", g.site.Titulo, postagens); err != nil {
+		return g.total, err
+	}
+
+	return g.total, g.copiarArquivosEstaticos()
+}
+
+func (g *gerador) carregarTemplates() error {
+
+	pasta := path.Join("layouts", g.opcoes.Layout)
+	if _, err := fs.Stat(layouts, path.Join(pasta, "base.html")); err != nil {
+		return fmt.Errorf("layout %q não encontrado (disponíveis: %s)", g.opcoes.Layout, strings.Join(Layouts(), ", "))
+	}
+
+	funcoes := template.FuncMap{
+		"resumo": func(raiz string, postagem model.Postagem) map[string]interface{} {
+			return map[string]interface{}{"Raiz": raiz, "Postagem": postagem}
+		},
+	}
+
+	for _, pagina := range []string{"index", "postagem", "tema", "autor"} {
+		t, err := template.New(pagina).Funcs(funcoes).ParseFS(layouts, path.Join(pasta, "base.html"), path.Join(pasta, pagina+".html"))
+		if err != nil {
+			return err
+		}
+		g.paginas[pagina] = t
+	}
+	return nil
+}
+
+func (g *gerador) gerarIndice(postagens []model.Postagem) error {
+
+	totalPaginas := (len(postagens) + g.opcoes.PorPagina - 1) / g.opcoes.PorPagina
+	if totalPaginas == 0 {
+		totalPaginas = 1
+	}
+
+	for pagina := 1; pagina <= totalPaginas; pagina++ {
+		inicio := (pagina - 1) * g.opcoes.PorPagina
+		fim := inicio + g.opcoes.PorPagina
+		if fim > len(postagens) {
+			fim = len(postagens)
+		}
+
+		dados := map[string]interface{}{
+			"Site":         g.site,
+			"Postagens":    postagens[inicio:fim],
+			"Pagina":       pagina,
+			"TotalPaginas": totalPaginas,
+			"Anterior":     "",
+			"Proxima":      "",
+		}
+		if pagina > 1 {
+			dados["Anterior"] = g.urlPagina(pagina - 1)
+		}
+		if pagina < totalPaginas {
+			dados["Proxima"] = g.urlPagina(pagina + 1)
+		}
+
+		arquivo := "index.html"
+		if pagina > 1 {
+			arquivo = path.Join("pagina", fmt.Sprint(pagina), "index.html")
+		}
+		if err := g.renderizar(arquivo, "index", dados); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *gerador) urlPagina(pagina int) string {
+	if pagina == 1 {
+		return g.site.Raiz + "/"
+	}
+	return fmt.Sprintf("%s/pagina/%d/", g.site.Raiz, pagina)
+}
+
+func (g *gerador) renderizar(arquivo string, pagina string, dados interface{}) error {
+	var conteudo bytes.Buffer
+	if err := g.paginas[pagina].ExecuteTemplate(&conteudo, "base", dados); err != nil {
+		return fmt.Errorf("%s: %w", arquivo, err)
+	}
+	return g.gravar(arquivo, conteudo.Bytes())
+}
+
+func (g *gerador) gravar(arquivo string, conteudo []byte) error {
+	destino := filepath.Join(g.opcoes.Saida, filepath.FromSlash(arquivo))
+	if err := os.MkdirAll(filepath.Dir(destino), 0o755); err != nil {
+		return err
+	}
+	g.total++
+	return os.WriteFile(destino, conteudo, 0o644)
+}
+
+// copiarArquivosEstaticos copia tudo que não é template (CSS, imagens) do layout
+func (g *gerador) copiarArquivosEstaticos() error {
+	pasta := path.Join("layouts", g.opcoes.Layout)
+	return fs.WalkDir(layouts, pasta, func(caminho string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(caminho) == ".html" {
+			return err
+		}
+		conteudo, err := layouts.ReadFile(caminho)
+		if err != nil {
+			return err
+		}
+		return g.gravar(strings.TrimPrefix(caminho, pasta+"/"), conteudo)
+	})
+}
+
+type rss struct {
+	XMLName xml.Name `xml:"rss"`
+	Versao  string   `xml:"version,attr"`
+	Canal   struct {
+		Titulo    string    `xml:"title"`
+		Link      string    `xml:"link"`
+		Descricao string    `xml:"description"`
+		Itens     []itemRSS `xml:"item"`
+	} `xml:"channel"`
+}
+
+type itemRSS struct {
+	Titulo    string `xml:"title"`
+	Link      string `xml:"link"`
+	GUID      string `xml:"guid"`
+	Categoria string `xml:"category"`
+	Data      string `xml:"pubDate"`
+	Descricao string `xml:"description"`
+}
+
+const itensPorFeed = 20
+
+func (g *gerador) gerarFeed(arquivo string, titulo string, postagens []model.Postagem) error {
+
+	var feed rss
+	feed.Versao = "2.0"
+	feed.Canal.Titulo = titulo
+	feed.Canal.Link = g.site.Raiz + "/"
+	feed.Canal.Descricao = titulo
+
+	for i, postagem := range postagens {
+		if i == itensPorFeed {
+			break
+		}
+		link := fmt.Sprintf("%s/postagens/%s/", g.site.Raiz, postagem.Slug)
+		feed.Canal.Itens = append(feed.Canal.Itens, itemRSS{
+			Titulo:    postagem.Titulo,
+			Link:      link,
+			GUID:      link,
+			Categoria: postagem.Tema.Descricao,
+			Data:      postagem.UpdatedAt.Format(time.RFC1123Z),
+			Descricao: postagem.Texto,
+		})
+	}
+
+	conteudo, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return g.gravar(arquivo, append([]byte(xml.Header), conteudo...))
+}